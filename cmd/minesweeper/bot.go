@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+var errBotTimeout = errors.New("bot did not answer in time")
+
+// A bot loses after maxBotErrors rejected moves in a row, or when it made
+// botMovesPerCell moves per cell of the board without ending the game
+const (
+	maxBotErrors    = 10
+	botMovesPerCell = 4
+)
+
+// botState is the JSON line sent to a bot before every move ("state") and
+// once when the game is over ("end").
+//
+// Board holds one string per row with the characters of
+// minesweeper.CellView: '.' hidden, 'F' flagged, '0'-'8' revealed and '*' a
+// mine (only sent once the game is over).
+type botState struct {
+	Type   string   `json:"type"`
+	Rows   int      `json:"rows"`
+	Cols   int      `json:"cols"`
+	Mines  int      `json:"mines"`
+	Flags  int      `json:"flags"`
+	Moves  int      `json:"moves"`
+	Status string   `json:"status"`
+	Board  []string `json:"board"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// botMove is the JSON line a bot answers with. Row and Col are 0-based,
+// they are nil if the line doesn't have them.
+type botMove struct {
+	Action string `json:"action"`
+	Row    *int   `json:"row"`
+	Col    *int   `json:"col"`
+}
+
+// cell returns the cell of the move, both the row and the column are
+// required.
+func (move *botMove) cell() (row, col int, err error) {
+	if move.Row == nil || move.Col == nil {
+		return 0, 0, errors.New("row and col are required")
+	}
+
+	return *move.Row, *move.Col, nil
+}
+
+// Bot is an external program playing the game over stdin/stdout. cmd is
+// nil for bots that play over pipes without a process.
+type Bot struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string
+	timeout time.Duration
+}
+
+// startBot launches the bot command. The command is split on whitespace and
+// is not run through a shell.
+func startBot(command string, timeout time.Duration) (*Bot, error) {
+	parts := strings.Fields(command)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("empty bot command")
+	}
+
+	cmd := exec.Command(parts[0], parts[1:]...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	bot := newBot(stdin, stdout, timeout)
+	bot.cmd = cmd
+
+	return bot, nil
+}
+
+// newBot creates a bot playing over the pipes, without a process.
+func newBot(stdin io.WriteCloser, stdout io.Reader, timeout time.Duration) *Bot {
+	bot := &Bot{
+		stdin:   stdin,
+		lines:   make(chan string),
+		timeout: timeout,
+	}
+
+	// Read the bot output in the background so a move can time out
+	go func() {
+		defer close(bot.lines)
+
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			bot.lines <- scanner.Text()
+		}
+	}()
+
+	return bot
+}
+
+// send writes a state as a single JSON line to the bot.
+func (bot *Bot) send(state *botState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	_, err = bot.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// Move sends the state to the bot and waits for its answer. The bot has
+// the timeout for the whole move, empty lines don't extend it.
+func (bot *Bot) Move(state *botState) (*botMove, error) {
+	if err := bot.send(state); err != nil {
+		return nil, err
+	}
+
+	deadline := time.NewTimer(bot.timeout)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case line, ok := <-bot.lines:
+			if !ok {
+				return nil, fmt.Errorf("bot closed its output")
+			}
+
+			// Allow empty lines between moves
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			move := &botMove{}
+			if err := json.Unmarshal([]byte(line), move); err != nil {
+				return nil, fmt.Errorf("invalid bot move %q: %w", line, err)
+			}
+
+			return move, nil
+		case <-deadline.C:
+			return nil, errBotTimeout
+		}
+	}
+}
+
+// Close sends the final state, closes the bot's stdin and waits for it to
+// exit. A bot that does not exit within the timeout is killed.
+func (bot *Bot) Close(state *botState) error {
+	if state != nil {
+		// The bot may already be gone, the final state is best effort
+		bot.send(state)
+	}
+
+	bot.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		// Drain the output so the bot never blocks on a full pipe
+		for range bot.lines {
+		}
+
+		if bot.cmd == nil {
+			done <- nil
+			return
+		}
+
+		done <- bot.cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(bot.timeout):
+		if bot.cmd == nil {
+			return errBotTimeout
+		}
+
+		bot.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+// newBotState builds the state of the board as seen by the player.
+func newBotState(board *minesweeper.Board, moves int) *botState {
+	view := board.PlayerView()
+
+	return &botState{
+		Type:   "state",
+		Rows:   view.Rows,
+		Cols:   view.Cols,
+		Mines:  view.NumMines,
+		Flags:  view.Flags,
+		Moves:  moves,
+		Status: view.Status.String(),
+		Board:  view.Lines(),
+	}
+}
+
+// applyBotMove applies a move to the board. It returns true if the game is over.
+func applyBotMove(board *minesweeper.Board, move *botMove) (gameOver bool, err error) {
+	row, col, err := move.cell()
+	if err != nil {
+		return false, err
+	}
+
+	if row < 0 || row >= board.Rows || col < 0 || col >= board.Cols {
+		return false, fmt.Errorf("cell (%d, %d) is outside the board", row, col)
+	}
+
+	switch strings.ToLower(move.Action) {
+	case "reveal", "r":
+		if board.Reveal(row, col) {
+			return true, nil
+		}
+	case "flag", "f":
+		board.ToggleFlag(row, col)
+	default:
+		return false, fmt.Errorf("unknown action %q", move.Action)
+	}
+
+	return board.RevealedPercentage() == 1, nil
+}
+
+// playBot lets the external program configured with -bot play a game.
+func playBot(config *Config) {
+	board := newGameBoard(config)
+
+	bot, err := startBot(config.bot, config.botTimeout)
+	if err != nil {
+		fmt.Fprintln(config.out, "Error starting bot:", err)
+		os.Exit(1)
+	}
+
+	runBot(board, bot, config)
+}
+
+// runBot plays the game with the bot until the game is over or the bot
+// fails, then closes the bot.
+func runBot(board *minesweeper.Board, bot *Bot, config *Config) {
+	startTime := time.Now()
+	gameOver := false
+	moves := 0
+	errs := 0
+	lastErr := ""
+
+	for !gameOver {
+		if config.clear {
+			fmt.Fprintln(config.out, dClear)
+		}
+
+		// Header and the empty line of the clear
+		fitViewport(board, config, 5)
+
+		printHeader(board, config)
+		display(board, config, false)
+
+		state := newBotState(board, moves)
+		state.Error = lastErr
+
+		move, err := bot.Move(state)
+		if err != nil {
+			fmt.Fprintln(config.out, "Bot error:", err)
+			break
+		}
+
+		moves++
+
+		gameOver, err = applyBotMove(board, move)
+		lastErr = ""
+		if err != nil {
+			lastErr = err.Error()
+			errs++
+		} else {
+			errs = 0
+		}
+
+		if errs >= maxBotErrors {
+			fmt.Fprintf(config.out, "Bot error: %d moves in a row were rejected, the last one: %s\n", errs, lastErr)
+			break
+		}
+
+		if !gameOver && moves >= botMovesPerCell*board.Rows*board.Cols {
+			fmt.Fprintf(config.out, "Bot error: the game didn't end after %d moves\n", moves)
+			break
+		}
+	}
+
+	end := newBotState(board, moves)
+	end.Type = "end"
+
+	if err := bot.Close(end); err != nil {
+		fmt.Fprintln(config.out, "Bot exited with error:", err)
+	}
+
+	printStatistics(board, startTime, config, true)
+}
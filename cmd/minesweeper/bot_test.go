@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TechMDW/minesweeper/internal/util"
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// fakeBot starts a bot over pipes. play gets the states sent to the bot and
+// writes its answers.
+func fakeBot(t *testing.T, timeout time.Duration, play func(states *bufio.Scanner, out io.Writer)) *Bot {
+	t.Helper()
+
+	stdinR, stdinW := io.Pipe()
+	stdoutR, stdoutW := io.Pipe()
+
+	go func() {
+		play(bufio.NewScanner(stdinR), stdoutW)
+		stdoutW.Close()
+
+		// Drain the states until the game closes stdin
+		io.Copy(io.Discard, stdinR)
+	}()
+
+	return newBot(stdinW, stdoutR, timeout)
+}
+
+func TestBotMove(t *testing.T) {
+	var got botState
+
+	bot := fakeBot(t, time.Second, func(states *bufio.Scanner, out io.Writer) {
+		if states.Scan() {
+			json.Unmarshal(states.Bytes(), &got)
+		}
+
+		// Empty lines between moves are skipped
+		fmt.Fprint(out, "\n  \n{\"action\":\"reveal\",\"row\":1,\"col\":2}\n")
+	})
+
+	board := minesweeper.NewBoard(3, 4, 1, &minesweeper.BoardOptions{Seed: 1}, nil)
+
+	move, err := bot.Move(newBotState(board, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if row, col, err := move.cell(); move.Action != "reveal" || err != nil || row != 1 || col != 2 {
+		t.Errorf("Expected a reveal of 1, 2, but got %+v", move)
+	}
+
+	if got.Type != "state" || got.Rows != 3 || got.Cols != 4 || len(got.Board) != 3 || got.Board[0] != "...." {
+		t.Errorf("Expected the state of a hidden 3 X 4 board, but got %+v", got)
+	}
+
+	if err := bot.Close(nil); err != nil {
+		t.Errorf("Expected the bot to exit, but got %v", err)
+	}
+}
+
+func TestBotTimeout(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	// Empty lines don't extend the time of a move
+	bot := fakeBot(t, 100*time.Millisecond, func(states *bufio.Scanner, out io.Writer) {
+		states.Scan()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+				fmt.Fprintln(out)
+			}
+		}
+	})
+
+	board := minesweeper.NewBoard(3, 3, 1, &minesweeper.BoardOptions{Seed: 1}, nil)
+
+	start := time.Now()
+	if _, err := bot.Move(newBotState(board, 0)); !errors.Is(err, errBotTimeout) {
+		t.Errorf("Expected a timeout, but got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the move to time out after 100ms, but it took %s", elapsed)
+	}
+}
+
+func TestBotInvalid(t *testing.T) {
+	board := minesweeper.NewBoard(3, 3, 1, &minesweeper.BoardOptions{Seed: 1}, nil)
+
+	bot := fakeBot(t, time.Second, func(states *bufio.Scanner, out io.Writer) {
+		states.Scan()
+		fmt.Fprintln(out, "reveal 1 1")
+	})
+
+	if _, err := bot.Move(newBotState(board, 0)); err == nil || !strings.Contains(err.Error(), "invalid bot move") {
+		t.Errorf("Expected an invalid move, but got %v", err)
+	}
+
+	bot = fakeBot(t, time.Second, func(states *bufio.Scanner, out io.Writer) {
+		states.Scan()
+	})
+
+	if _, err := bot.Move(newBotState(board, 0)); err == nil || !strings.Contains(err.Error(), "closed its output") {
+		t.Errorf("Expected the bot to close its output, but got %v", err)
+	}
+}
+
+func TestApplyBotMove(t *testing.T) {
+	board := minesweeper.NewBoard(3, 3, 1, &minesweeper.BoardOptions{Seed: 1}, nil)
+
+	if _, err := applyBotMove(board, &botMove{Action: "reveal", Row: util.IntPtr(3), Col: util.IntPtr(0)}); err == nil {
+		t.Error("Expected an error for a cell outside the board")
+	}
+
+	if _, err := applyBotMove(board, &botMove{Action: "jump", Row: util.IntPtr(0), Col: util.IntPtr(0)}); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+
+	// A missing row or column is not the first one
+	if _, err := applyBotMove(board, &botMove{Action: "reveal", Row: util.IntPtr(0)}); err == nil || board.Cells[0][0].IsRevealed {
+		t.Errorf("Expected an error for a move without a column, but got %v", err)
+	}
+
+	if _, err := applyBotMove(board, &botMove{Action: "f", Row: util.IntPtr(0), Col: util.IntPtr(0)}); err != nil || !board.IsFlagged(0, 0) {
+		t.Errorf("Expected a flag on 0, 0, but got %v", err)
+	}
+
+	// Reveal every safe cell, the last one ends the game
+	gameOver := false
+	for r := 0; r < board.Rows; r++ {
+		for c := 0; c < board.Cols; c++ {
+			if !board.Cells[r][c].IsMine && !board.Cells[r][c].IsRevealed {
+				var err error
+				if gameOver, err = applyBotMove(board, &botMove{Action: "reveal", Row: util.IntPtr(r), Col: util.IntPtr(c)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+
+	if !gameOver || board.Status() != minesweeper.StatusWon {
+		t.Errorf("Expected the game to be won, but got %v", board.Status())
+	}
+}
+
+func TestPrintHeaderWithoutViewport(t *testing.T) {
+	board := minesweeper.NewBoard(3, 3, 1, &minesweeper.BoardOptions{Seed: 1}, nil)
+
+	var out strings.Builder
+	config := &Config{header: true, out: &out}
+
+	// Bots play without a terminal, the bar is as wide as the board
+	printHeader(board, config)
+
+	if !strings.Contains(out.String(), "["+strings.Repeat(" ", 7)+"] 0.0%") {
+		t.Errorf("Expected a bar of 7 characters, but got %q", out.String())
+	}
+
+	board.DisplayOptions.Viewport = &minesweeper.Viewport{}
+	out.Reset()
+	printHeader(board, config)
+
+	if !strings.Contains(out.String(), "0.0%") {
+		t.Errorf("Expected a bar, but got %q", out.String())
+	}
+}
+
+func TestRunBotStops(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+	}{
+		{"rejected", `{"action":"jump","row":0,"col":0}`, "moves in a row were rejected"},
+		{"missing cell", `{"action":"reveal"}`, "row and col are required"},
+		{"no progress", `{"action":"flag","row":0,"col":0}`, "didn't end after 36 moves"},
+	}
+
+	for _, test := range tests {
+		// The bot answers in time, forever
+		bot := fakeBot(t, time.Second, func(states *bufio.Scanner, out io.Writer) {
+			for states.Scan() {
+				fmt.Fprintln(out, test.answer)
+			}
+		})
+
+		var buf strings.Builder
+		config := parseFlags([]string{"-rows", "3", "-cols", "3", "-mines", "1", "-seed", "1", "-header=false"})
+		config.out = &buf
+		config.clear = false
+
+		done := make(chan struct{})
+		go func() {
+			runBot(newGameBoard(config), bot, config)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Expected the %s bot to be stopped", test.name)
+		}
+
+		if !strings.Contains(buf.String(), test.want) {
+			t.Errorf("Expected %q for the %s bot, but got %q", test.want, test.name, buf.String())
+		}
+	}
+}
+
+func TestBarWidthFollowsLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		cols    int
+		options *minesweeper.DisplayOptions
+	}{
+		{"default", 10, nil},
+		{"wide indices", 120, nil},
+		{"wide symbols", 8, &minesweeper.DisplayOptions{SymbolHidden: util.StringPtr("[]"), SymbolSeperator: util.StringPtr(" | ")}},
+		{"no left index", 8, &minesweeper.DisplayOptions{LeftIndex: util.BoolPtr(false)}},
+	}
+
+	for _, test := range tests {
+		board := minesweeper.NewBoard(3, test.cols, 1, &minesweeper.BoardOptions{Seed: 1}, test.options)
+
+		var out strings.Builder
+		printHeader(board, &Config{header: true, out: &out})
+
+		var rows strings.Builder
+		if err := (minesweeper.PlainRenderer{}).Render(&rows, board.PlayerView(), board.DisplayOptions); err != nil {
+			t.Fatal(err)
+		}
+
+		// The bar ends with the right edge of the cells
+		header := strings.Split(out.String(), "\n")
+		bar := header[len(header)-2]
+		row := strings.Split(rows.String(), "\n")[1]
+
+		if end, width := minesweeper.DisplayWidth(bar[:strings.Index(bar, "]")+1]), minesweeper.DisplayWidth(row); end != width {
+			t.Errorf("Expected the bar to end at %d for %s, but it ends at %d", width, test.name, end)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// Command is one command typed by the player. Several commands can be typed
+// on one line separated by ";".
+type Command struct {
+	Action string
+	Args   []string
+
+	// tokens holds the action followed by the arguments with their position
+	// in the line, used to point at the token an error is about
+	tokens []token
+}
+
+// token is a word of the input and its byte offset in the line.
+type token struct {
+	text string
+	pos  int
+}
+
+// end returns the offset right after the token.
+func (t token) end() int {
+	return t.pos + len(t.text)
+}
+
+// parseError is an error about a token of the input.
+type parseError struct {
+	pos int
+	msg string
+}
+
+func (e *parseError) Error() string {
+	return e.msg
+}
+
+func errorAt(t token, format string, a ...any) *parseError {
+	return &parseError{pos: t.pos, msg: fmt.Sprintf(format, a...)}
+}
+
+// formatError formats an error about the line. Errors about a token are
+// shown below the line with a caret pointing at the token.
+func formatError(line string, err error) string {
+	pe, ok := err.(*parseError)
+	if !ok {
+		return err.Error()
+	}
+
+	return fmt.Sprintf("  %s\n  %s^ %s", line, strings.Repeat(" ", minesweeper.DisplayWidth(line[:pe.pos])), pe.msg)
+}
+
+// parseLine splits a line into commands separated by ";". Empty commands
+// are skipped.
+func parseLine(line string) ([]*Command, error) {
+	var commands []*Command
+	var tokens []token
+
+	flush := func() {
+		if len(tokens) > 0 {
+			commands = append(commands, &Command{
+				Action: strings.ToLower(tokens[0].text),
+				Args:   texts(tokens[1:]),
+				tokens: tokens,
+			})
+		}
+
+		tokens = nil
+	}
+
+	start := -1
+	for i, r := range line + " " {
+		switch {
+		case r == ' ' || r == '\t' || r == ';':
+			if start >= 0 {
+				tokens = append(tokens, token{text: line[start:i], pos: start})
+				start = -1
+			}
+
+			if r == ';' {
+				flush()
+			}
+		case start < 0:
+			start = i
+		}
+	}
+
+	flush()
+
+	if len(commands) == 0 {
+		return nil, errInvalidInput
+	}
+
+	return commands, nil
+}
+
+func texts(tokens []token) []string {
+	texts := make([]string, len(tokens))
+	for i, t := range tokens {
+		texts[i] = t.text
+	}
+
+	return texts
+}
+
+// args returns the argument tokens of the command.
+func (c *Command) args() []token {
+	return c.tokens[1:]
+}
+
+// missing returns an error pointing right after the last token of the command.
+func (c *Command) missing(format string, a ...any) *parseError {
+	last := c.tokens[len(c.tokens)-1]
+	return &parseError{pos: last.end(), msg: fmt.Sprintf(format, a...)}
+}
+
+// parseCells parses the cells of a reveal or flag command.
+//
+// With numeric coordinates the first argument is the row followed by one or
+// more columns, inverted swaps them. Rows and columns can be ranges ("4-9"
+// or "4:9"), so "r 3 4-9" reveals a part of a row and "f 2:4 5:8" flags a
+// rectangle.
+//
+// With chess coordinates every argument is a cell ("C7") or a rectangle
+// between two cells ("C7:E9").
+func parseCells(command *Command, inverted bool, board *minesweeper.Board) ([]minesweeper.Position, error) {
+	args := command.args()
+
+	if *board.DisplayOptions.Coordinates == minesweeper.CoordinatesChess {
+		if len(args) < 1 {
+			return nil, command.missing("expected a cell like C7")
+		}
+
+		var cells []minesweeper.Position
+		for _, arg := range args {
+			from, to, err := parseCellRange(arg, board)
+			if err != nil {
+				return nil, err
+			}
+
+			cells = appendRect(cells, from.Row, to.Row, from.Col, to.Col)
+		}
+
+		return cells, nil
+	}
+
+	rowName, colName := "row", "column"
+	rowCount, colCount := board.Rows, board.Cols
+	if inverted {
+		rowName, colName = colName, rowName
+		rowCount, colCount = colCount, rowCount
+	}
+
+	if len(args) < 1 {
+		return nil, command.missing("expected a %s and a %s", rowName, colName)
+	}
+
+	if len(args) < 2 {
+		return nil, command.missing("expected a %s after the %s", colName, rowName)
+	}
+
+	fromX, toX, err := parseSpan(args[0], rowName, rowCount, board)
+	if err != nil {
+		return nil, err
+	}
+
+	var cells []minesweeper.Position
+	for _, arg := range args[1:] {
+		fromY, toY, err := parseSpan(arg, colName, colCount, board)
+		if err != nil {
+			return nil, err
+		}
+
+		if inverted {
+			cells = appendRect(cells, fromY, toY, fromX, toX)
+		} else {
+			cells = appendRect(cells, fromX, toX, fromY, toY)
+		}
+	}
+
+	return cells, nil
+}
+
+// parseSpan parses a number or a range of numbers ("4-9" or "4:9") and
+// returns the 0-based first and last index. name is the name of the number
+// used in errors and count the number of rows or columns it must be below.
+func parseSpan(t token, name string, count int, board *minesweeper.Board) (from, to int, err error) {
+	start := *board.DisplayOptions.StartIndex
+
+	parts, ok := splitRange(t.text)
+	if !ok {
+		return 0, 0, errorAt(t, "invalid %s %q, use a number or a range like 4-9", name, t.text)
+	}
+
+	values := make([]int, len(parts))
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, 0, errorAt(t, "invalid %s %q, use a number or a range like 4-9", name, t.text)
+		}
+
+		if value < start || value >= start+count {
+			return 0, 0, errorAt(t, "%s %d is outside the board (%d-%d)", name, value, start, start+count-1)
+		}
+
+		values[i] = value - start
+	}
+
+	from, to = values[0], values[len(values)-1]
+	if from > to {
+		from, to = to, from
+	}
+
+	return from, to, nil
+}
+
+// parseCellRange parses a cell ("C7") or a rectangle between two cells
+// ("C7:E9" or "C7-E9").
+func parseCellRange(t token, board *minesweeper.Board) (from, to minesweeper.Position, err error) {
+	parts, ok := splitRange(t.text)
+	if !ok {
+		return from, to, errorAt(t, "invalid cell %q, use a cell like C7 or a rectangle like C7:E9", t.text)
+	}
+
+	cells := make([]minesweeper.Position, len(parts))
+	for i, part := range parts {
+		cell, err := board.ParseCell(part)
+		if err != nil {
+			return from, to, errorAt(t, "%v", err)
+		}
+
+		cells[i] = cell
+	}
+
+	from, to = cells[0], cells[len(cells)-1]
+	if from.Row > to.Row {
+		from.Row, to.Row = to.Row, from.Row
+	}
+
+	if from.Col > to.Col {
+		from.Col, to.Col = to.Col, from.Col
+	}
+
+	return from, to, nil
+}
+
+// splitRange splits a range at its "-" or ":" into its two ends, a text
+// without one is a single part. It reports false for more than one
+// separator or an empty end, like in "-1", "4-" or ":4".
+func splitRange(text string) ([]string, bool) {
+	parts := strings.FieldsFunc(text, func(r rune) bool {
+		return r == '-' || r == ':'
+	})
+
+	if strings.Count(text, "-")+strings.Count(text, ":") != len(parts)-1 {
+		return nil, false
+	}
+
+	return parts, len(parts) == 1 || len(parts) == 2
+}
+
+// appendRect appends the cells of the rectangle, row by row.
+func appendRect(cells []minesweeper.Position, fromRow, toRow, fromCol, toCol int) []minesweeper.Position {
+	for row := fromRow; row <= toRow; row++ {
+		for col := fromCol; col <= toCol; col++ {
+			cells = append(cells, minesweeper.Position{Row: row, Col: col})
+		}
+	}
+
+	return cells
+}
+
+// parseInt parses the argument as a number.
+func parseInt(t token, name string) (int, error) {
+	value, err := strconv.Atoi(t.text)
+	if err != nil {
+		return 0, errorAt(t, "invalid %s %q, expected a number", name, t.text)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestParseCellsRanges(t *testing.T) {
+	board := minesweeper.NewBoard(10, 10, 10, &minesweeper.BoardOptions{Seed: 1}, nil)
+
+	tests := []struct {
+		line  string
+		cells int
+		pos   int
+	}{
+		{line: "r 3 4", cells: 1},
+		{line: "r 3 4-6", cells: 3},
+		{line: "r 3:4 6:4", cells: 6},
+		{line: "r 3 -1", pos: 4},
+		{line: "r 3 4-", pos: 4},
+		{line: "r :4 1", pos: 2},
+		{line: "r 3 4--6", pos: 4},
+		{line: "r 3 4-5-6", pos: 4},
+	}
+
+	for _, test := range tests {
+		commands, err := parseLine(test.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cells, err := parseCells(commands[0], false, board)
+
+		if test.cells > 0 {
+			if err != nil || len(cells) != test.cells {
+				t.Errorf("Expected %d cells for %q, but got %d (%v)", test.cells, test.line, len(cells), err)
+			}
+
+			continue
+		}
+
+		pe, ok := err.(*parseError)
+		if !ok || pe.pos != test.pos {
+			t.Errorf("Expected an error at %d for %q, but got %v", test.pos, test.line, err)
+		}
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	commands, err := parseLine("F 1 2;r 3 4-6 ;; ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(commands) != 2 {
+		t.Fatalf("Expected two commands, but got %d", len(commands))
+	}
+
+	if commands[0].Action != "f" || strings.Join(commands[0].Args, " ") != "1 2" {
+		t.Errorf("Expected f 1 2, but got %s %v", commands[0].Action, commands[0].Args)
+	}
+
+	// Tokens keep their offset in the line for the errors
+	if args := commands[1].args(); len(args) != 2 || args[1].text != "4-6" || args[1].pos != 10 {
+		t.Errorf("Expected 4-6 at 10, but got %+v", args)
+	}
+
+	if _, err := parseLine(" ; "); err == nil {
+		t.Errorf("Expected an error for a line without commands")
+	}
+}
+
+func TestParseCellsRectangles(t *testing.T) {
+	board := minesweeper.NewBoard(10, 10, 10, &minesweeper.BoardOptions{Seed: 1}, nil)
+
+	cells := func(line string, inverted bool) []minesweeper.Position {
+		t.Helper()
+
+		commands, err := parseLine(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cells, err := parseCells(commands[0], inverted, board)
+		if err != nil {
+			t.Fatalf("Expected the cells of %q, but got %v", line, err)
+		}
+
+		return cells
+	}
+
+	// Rows 2 to 4 and columns 5 to 8, 1-based
+	rect := cells("f 2:4 5:8", false)
+	if len(rect) != 12 || rect[0] != (minesweeper.Position{Row: 1, Col: 4}) || rect[11] != (minesweeper.Position{Row: 3, Col: 7}) {
+		t.Errorf("Expected a 3 X 4 rectangle from 1, 4 to 3, 7, but got %v", rect)
+	}
+
+	// Several columns of a row, ranges written backwards
+	if got := cells("r 3 1 9-8", false); len(got) != 3 || got[0] != (minesweeper.Position{Row: 2, Col: 0}) || got[1].Col != 7 || got[2].Col != 8 {
+		t.Errorf("Expected columns 0, 7 and 8 of row 2, but got %v", got)
+	}
+
+	// c takes the column first
+	if got := cells("c 3 1-2", true); len(got) != 2 || got[0] != (minesweeper.Position{Row: 0, Col: 2}) || got[1] != (minesweeper.Position{Row: 1, Col: 2}) {
+		t.Errorf("Expected rows 0 and 1 of column 2, but got %v", got)
+	}
+
+	chess := minesweeper.CoordinatesChess
+	board.DisplayOptions.Coordinates = &chess
+
+	if got := cells("f B2-A1 J10", false); len(got) != 5 || got[0] != (minesweeper.Position{Row: 0, Col: 0}) || got[4] != (minesweeper.Position{Row: 9, Col: 9}) {
+		t.Errorf("Expected the rectangle A1:B2 and J10, but got %v", got)
+	}
+
+	for _, line := range []string{"f A1:", "f :B2", "f A1:B2:C3"} {
+		commands, _ := parseLine(line)
+		if _, err := parseCells(commands[0], false, board); err == nil {
+			t.Errorf("Expected an error for %q", line)
+		}
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	line := "f 1 2; r 3 x"
+
+	commands, err := parseLine(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	board := minesweeper.NewBoard(10, 10, 10, &minesweeper.BoardOptions{Seed: 1}, nil)
+
+	_, err = parseCells(commands[1], false, board)
+	if err == nil {
+		t.Fatal("Expected an error for the column x")
+	}
+
+	want := "  f 1 2; r 3 x\n" +
+		"             ^ invalid column \"x\", use a number or a range like 4-9"
+	if got := formatError(line, err); got != want {
+		t.Errorf("Expected the caret under x:\n%s\nbut got:\n%s", want, got)
+	}
+
+	// Missing arguments point right after the command
+	_, err = parseCells(commands[0], false, &minesweeper.Board{Rows: 1, Cols: 1, DisplayOptions: board.DisplayOptions})
+	if err == nil || !strings.HasSuffix(formatError(line, err), "outside the board (1-1)") {
+		t.Errorf("Expected an error at the row, but got %v", err)
+	}
+
+	commands, _ = parseLine("r")
+	_, err = parseCells(commands[0], false, board)
+	if got := formatError("r", err); got != "  r\n   ^ expected a row and a column" {
+		t.Errorf("Expected the caret after r, but got %q", got)
+	}
+}
+
+func TestHandleInputRepeat(t *testing.T) {
+	config := parseFlags([]string{"-rows", "5", "-cols", "5", "-mines", "1", "-seed", "1"})
+	config.out = io.Discard
+
+	board := newGameBoard(config)
+
+	if _, _, err := handleInput(".", board, config); err == nil {
+		t.Errorf("Expected an error without a line to repeat")
+	}
+
+	if _, _, err := handleInput("f 1 1; f 2 2", board, config); err != nil || board.FlagsCount() != 2 {
+		t.Fatalf("Expected two flags, but got %d (%v)", board.FlagsCount(), err)
+	}
+
+	// . plays the whole line again, the flags are removed
+	if _, _, err := handleInput(".", board, config); err != nil || board.FlagsCount() != 0 {
+		t.Errorf("Expected the flags to be removed, but got %d (%v)", board.FlagsCount(), err)
+	}
+
+	// A line that fails stops at the failing command
+	_, _, err := handleInput("f 3 3; f 9 9; f 4 4", board, config)
+	if err == nil || board.FlagsCount() != 1 || !strings.Contains(err.Error(), "         ^ row 9 is outside the board (1-5)") {
+		t.Errorf("Expected the error at 9 after one flag, but got %d flags and %v", board.FlagsCount(), err)
+	}
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// Default names of the players of a duel
+const (
+	duelPlayer1  = "Player 1"
+	duelPlayer2  = "Player 2"
+	duelComputer = "Computer"
+)
+
+// playDuel plays a duel of two players taking turns at the terminal, or of
+// one player against the computer with -ai.
+func playDuel(config *Config) {
+	board := newGameBoard(config)
+	duel := minesweeper.NewDuel(board)
+	config.duel = duel
+
+	names := append(config.players, "", "")
+	if names[0] == "" {
+		names[0] = duelPlayer1
+	}
+
+	switch {
+	case config.ai:
+		names[1] = duelComputer
+	case names[1] == "":
+		names[1] = duelPlayer2
+	}
+
+	duel.Join(names[0])
+	second, _ := duel.Join(names[1])
+
+	var computer *minesweeper.Player
+	if config.ai {
+		computer = second
+	}
+
+	for !duel.Over() {
+		if duel.Turn() == computer {
+			config.message = strings.Join(playComputer(duel, computer), "\n")
+			continue
+		}
+
+		drawDuel(board, duel, config, duel.Turn())
+
+		if !config.in.Scan() {
+			return
+		}
+
+		moves, quit, err := handleDuelInput(config.in.Text(), duel, duel.Turn(), config)
+		config.message = strings.Join(moves, "\n")
+		if err != nil {
+			config.message = err.Error()
+		}
+
+		if quit {
+			return
+		}
+	}
+
+	printDuelEnd(board, duel, config)
+}
+
+// playComputer plays the turn of the computer and returns its moves.
+func playComputer(duel *minesweeper.Duel, computer *minesweeper.Player) (moves []string) {
+	for duel.Turn() == computer && !duel.Over() {
+		pos := duel.AIMove()
+
+		mine, err := duel.Reveal(computer, pos.Row, pos.Col)
+		if err != nil {
+			break
+		}
+
+		moves = append(moves, duelMove(duel.Board, computer, pos, mine))
+	}
+
+	return moves
+}
+
+// duelMove describes a move of a duel.
+func duelMove(board *minesweeper.Board, p *minesweeper.Player, pos minesweeper.Position, mine bool) string {
+	if mine {
+		return fmt.Sprintf("%s found a mine at %s and plays again", p.Name, board.FormatCell(pos.Row, pos.Col))
+	}
+
+	return fmt.Sprintf("%s revealed %s", p.Name, board.FormatCell(pos.Row, pos.Col))
+}
+
+// handleDuelInput runs the commands of a line for the player and returns
+// the moves made. Only reveals, help and quit are allowed, flags would give
+// the mines away.
+func handleDuelInput(line string, duel *minesweeper.Duel, p *minesweeper.Player, config *Config) (moves []string, quit bool, err error) {
+	commands, err := parseLine(line)
+	if err != nil {
+		return nil, false, &inputError{line: line, err: err}
+	}
+
+	for _, command := range commands {
+		switch command.Action {
+		case "r", "c":
+			cells, err := parseCells(command, command.Action == "c", duel.Board)
+			if err != nil {
+				return moves, false, &inputError{line: line, err: err}
+			}
+
+			if len(cells) != 1 {
+				return moves, false, &inputError{line: line, err: errorAt(command.tokens[0], "reveal one cell per move")}
+			}
+
+			mine, err := duel.Reveal(p, cells[0].Row, cells[0].Col)
+			if err != nil {
+				return moves, false, err
+			}
+
+			moves = append(moves, duelMove(duel.Board, p, cells[0], mine))
+
+			// The other commands of the line wait for the next turn
+			if !mine {
+				return moves, false, nil
+			}
+		case "h", "help", "imlost":
+			if config.clear {
+				fmt.Fprintln(config.out, dClear)
+			}
+
+			printDuelHelp(config)
+		case "q", "quit", "exit":
+			return moves, true, nil
+		default:
+			return moves, false, &inputError{line: line, err: errorAt(command.tokens[0], "unknown command %q, a duel has r, h and q", command.tokens[0].text)}
+		}
+	}
+
+	return moves, false, nil
+}
+
+// drawDuel draws the screen of a duel for the player you: the scores, the
+// board with the claimed mines, the message and whose turn it is.
+func drawDuel(board *minesweeper.Board, duel *minesweeper.Duel, config *Config, you *minesweeper.Player) {
+	if config.clear {
+		fmt.Fprintln(config.out, dClear)
+	}
+
+	// Header, message, footer, input and the empty line of the clear
+	fitViewport(board, config, 6)
+
+	fmt.Fprintf(config.out, "Flags duel: find %d of the %d mines to win\n", duel.Majority(), board.NumMines)
+	printScores(board, duel, config)
+
+	display(board, config, false)
+
+	if config.message != "" {
+		fmt.Fprintln(config.out, config.message)
+		config.message = ""
+	}
+
+	turn := duel.Turn()
+
+	switch {
+	case turn == nil:
+		fmt.Fprintln(config.out, "Waiting for an opponent ...")
+	case turn != you:
+		fmt.Fprintf(config.out, "Waiting for %s ...\n", turn.Name)
+	case config.coordinates == minesweeper.CoordinatesChess:
+		fmt.Fprintf(config.out, "%s, your turn: (r <cell> = reveal, h = help, q = quit)\n", turn.Name)
+	default:
+		fmt.Fprintf(config.out, "%s, your turn: (r <row> <col> = reveal, h = help, q = quit)\n", turn.Name)
+	}
+}
+
+// printScores prints the players in their colors with the mines they found,
+// the player whose turn it is is marked.
+func printScores(board *minesweeper.Board, duel *minesweeper.Duel, config *Config) {
+	var scores []string
+	for _, p := range duel.Players() {
+		name := minesweeper.Style{Foreground: p.Color, Bold: true}.Paint(p.Name, config.colorDepth)
+
+		score := fmt.Sprintf("%s %d", name, p.Flags)
+		if p == duel.Turn() && !duel.Over() {
+			score = "> " + score
+		}
+
+		scores = append(scores, score)
+	}
+
+	board.Fprintln(config.out, strings.Join(scores, "   "))
+}
+
+// printDuelEnd prints the board with all mines and the result of the duel.
+func printDuelEnd(board *minesweeper.Board, duel *minesweeper.Duel, config *Config) {
+	if config.clear {
+		fmt.Fprintln(config.out, dClear)
+	}
+
+	display(board, config, true)
+	fmt.Fprintln(config.out)
+
+	if config.message != "" {
+		fmt.Fprintln(config.out, config.message)
+		config.message = ""
+	}
+
+	players := duel.Players()
+	a, b := players[0], players[1]
+
+	switch winner := duel.Winner(); {
+	case winner == nil:
+		fmt.Fprintf(config.out, "Draw, %d to %d!\n", a.Flags, b.Flags)
+	case a.Left || b.Left:
+		fmt.Fprintf(config.out, "%s won, the opponent left!\n", winner.Name)
+	default:
+		loser := a
+		if winner == a {
+			loser = b
+		}
+
+		fmt.Fprintf(config.out, "%s won %d to %d!\n", winner.Name, winner.Flags, loser.Flags)
+	}
+
+	fmt.Fprintln(config.out, "Seed:", config.seed)
+}
+
+func printDuelHelp(config *Config) {
+	fmt.Fprintln(config.out, "Flags duel: take turns to reveal cells and find the mines.")
+	fmt.Fprintln(config.out, "A mine is a point and another turn, a safe cell passes the turn.")
+	fmt.Fprintln(config.out, "The first player to find the majority of the mines wins.")
+	fmt.Fprintln(config.out)
+
+	if config.coordinates == minesweeper.CoordinatesChess {
+		fmt.Fprintln(config.out, "r <cell> = reveal the cell (r C7)")
+	} else {
+		fmt.Fprintln(config.out, "r <row> <col> = reveal cell at position (row, col)")
+		fmt.Fprintln(config.out)
+		fmt.Fprintln(config.out, "c <col> <row> = reveal cell at position (col, row)")
+	}
+
+	fmt.Fprintln(config.out)
+	fmt.Fprintln(config.out, "q = quit")
+	fmt.Fprintln(config.out)
+
+	if config.noPrompts {
+		return
+	}
+
+	fmt.Fprintln(config.out, "Just press ENTER(↵) to continue ...")
+	config.in.Scan()
+}
+
+// duelGame is a duel of two connections of the lobby, or of a connection
+// against the computer. The mutex guards the duel, its board and the
+// screens of the players.
+type duelGame struct {
+	code       string
+	difficulty minesweeper.Difficulty
+	seed       int64
+	startTime  time.Time
+
+	mu       sync.Mutex
+	duel     *minesweeper.Duel
+	computer *minesweeper.Player
+	players  map[*lobbyDuelist]struct{}
+	closed   bool
+}
+
+// lobbyDuelist is a connection playing a duel. Every player has their own
+// display options, they are swapped into the board while the mutex is held.
+type lobbyDuelist struct {
+	config  *Config
+	options *minesweeper.DisplayOptions
+	player  *minesweeper.Player
+}
+
+// newDuel creates a duel of the lobby. Duels against the computer are not
+// listed, nobody can join them.
+func (l *lobby) newDuel(difficulty minesweeper.Difficulty, computer bool) *duelGame {
+	seed := time.Now().UnixNano()
+	board := minesweeper.NewBoard(difficulty.Rows, difficulty.Cols, difficulty.Mines, &minesweeper.BoardOptions{Seed: seed}, nil)
+
+	dg := &duelGame{
+		difficulty: difficulty,
+		seed:       seed,
+		startTime:  time.Now(),
+		duel:       minesweeper.NewDuel(board),
+		players:    make(map[*lobbyDuelist]struct{}),
+	}
+
+	if computer {
+		return dg
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dg.code = l.newCode()
+	l.duels[dg.code] = dg
+
+	return dg
+}
+
+// duelGame returns the duel with the code.
+func (l *lobby) duelGame(code string) (*duelGame, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dg, ok := l.duels[strings.ToUpper(code)]
+	return dg, ok
+}
+
+// playLobbyDuel plays a duel until it is over or the player quits. The
+// first player waits for the second, against the computer the computer
+// joins at once.
+func (l *lobby) playLobbyDuel(config *Config, dg *duelGame, computer bool) {
+	useDifficulty(config, dg.difficulty, dg.seed)
+
+	// A question would hold the duel until it is answered
+	config.noPrompts = true
+	defer func() {
+		config.noPrompts = false
+	}()
+
+	p := &lobbyDuelist{config: config, options: newDisplayOptions(config)}
+	board := dg.duel.Board
+
+	dg.mu.Lock()
+	player, err := dg.duel.Join(config.name)
+	if dg.closed || err != nil {
+		dg.mu.Unlock()
+		config.message = fmt.Sprintf("The duel %s is closed", dg.code)
+		return
+	}
+
+	p.player = player
+	dg.players[p] = struct{}{}
+	config.duel = dg.duel
+
+	if computer {
+		dg.computer, _ = dg.duel.Join(duelComputer)
+	}
+
+	// A full duel can't be joined
+	if dg.duel.Turn() != nil {
+		l.closeDuel(dg)
+	}
+
+	dg.redraw(p, nil)
+	dg.mu.Unlock()
+
+	defer func() {
+		dg.mu.Lock()
+		defer dg.mu.Unlock()
+
+		delete(dg.players, p)
+		config.duel = nil
+
+		if !dg.duel.Over() {
+			dg.duel.Leave(p.player)
+			dg.redraw(p, []string{p.player.Name + " left"})
+		}
+
+		l.closeDuel(dg)
+	}()
+
+	if dg.duel.Turn() == nil {
+		config.message = fmt.Sprintf("Duel %s, your opponent joins with: join %s", dg.code, dg.code)
+	}
+
+	for {
+		dg.mu.Lock()
+		board.DisplayOptions = p.options
+
+		over := dg.duel.Over()
+		if over {
+			printLobbyDuelEnd(board, dg.duel, config)
+		} else {
+			drawDuel(board, dg.duel, config, p.player)
+		}
+		dg.mu.Unlock()
+
+		if !config.in.Scan() || over {
+			return
+		}
+
+		line := config.in.Text()
+
+		// The help waits for ENTER outside of the duel
+		if isHelp(line) {
+			if config.clear {
+				fmt.Fprintln(config.out, dClear)
+			}
+
+			config.noPrompts = false
+			printDuelHelp(config)
+			config.noPrompts = true
+			continue
+		}
+
+		dg.mu.Lock()
+		board.DisplayOptions = p.options
+
+		// The opponent left while the player was typing, the end is shown
+		if dg.duel.Over() {
+			dg.mu.Unlock()
+			return
+		}
+
+		var moves []string
+		var quit bool
+
+		switch {
+		case dg.duel.Turn() != nil:
+			moves, quit, err = handleDuelInput(line, dg.duel, p.player, config)
+		case isQuit(line):
+			quit = true
+		default:
+			err = errors.New("Waiting for an opponent, moves start once they join")
+		}
+
+		if dg.computer != nil && dg.duel.Turn() == dg.computer {
+			moves = append(moves, playComputer(dg.duel, dg.computer)...)
+		}
+
+		config.message = strings.Join(moves, "\n")
+		if err != nil {
+			config.message = err.Error()
+		}
+
+		if len(moves) > 0 {
+			dg.redraw(p, moves)
+		}
+		dg.mu.Unlock()
+
+		if quit {
+			return
+		}
+	}
+}
+
+// closeDuel takes the duel out of the lobby once it is full. The mutex of
+// the duel must be held.
+func (l *lobby) closeDuel(dg *duelGame) {
+	if dg.code == "" {
+		return
+	}
+
+	l.mu.Lock()
+	delete(l.duels, dg.code)
+	l.mu.Unlock()
+
+	if len(dg.players) == 0 {
+		dg.closed = true
+	}
+}
+
+// redraw draws the screen of the opponent of p with the moves made. The
+// mutex must be held.
+func (dg *duelGame) redraw(p *lobbyDuelist, moves []string) {
+	board := dg.duel.Board
+
+	for other := range dg.players {
+		if other == p {
+			continue
+		}
+
+		board.DisplayOptions = other.options
+		other.config.message = strings.Join(moves, "\n")
+
+		if dg.duel.Over() {
+			printLobbyDuelEnd(board, dg.duel, other.config)
+		} else {
+			drawDuel(board, dg.duel, other.config, other.player)
+		}
+	}
+
+	board.DisplayOptions = p.options
+}
+
+// printLobbyDuelEnd prints the result of a duel of the lobby.
+func printLobbyDuelEnd(board *minesweeper.Board, duel *minesweeper.Duel, config *Config) {
+	printDuelEnd(board, duel, config)
+	fmt.Fprintln(config.out)
+	fmt.Fprintln(config.out, "Just press ENTER(↵) to return to the lobby ...")
+}
+
+// isQuit reports whether the line is a quit command.
+func isQuit(line string) bool {
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "q", "quit", "exit":
+		return true
+	}
+
+	return false
+}
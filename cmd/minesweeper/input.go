@@ -0,0 +1,188 @@
+package main
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Names of special keys read in full-screen mode
+const (
+	keyUp    = "up"
+	keyDown  = "down"
+	keyLeft  = "left"
+	keyRight = "right"
+	keyEnter = "enter"
+	keySpace = "space"
+	keyTab   = "tab"
+	keyEsc   = "esc"
+	keyCtrlC = "ctrl+c"
+)
+
+// Mouse buttons reported by the terminal
+const (
+	mouseLeft   = 0
+	mouseMiddle = 1
+	mouseRight  = 2
+)
+
+// Time to wait for the rest of an escape sequence cut by a read, a lone
+// escape after it is the ESC key. Sequences longer than maxEscapeLength
+// are never completed.
+const (
+	escapeTimeout   = 50 * time.Millisecond
+	maxEscapeLength = 32
+)
+
+// Escape codes to enable and disable SGR mouse reporting of button presses
+const (
+	tMouseOn  = "\033[?1000h\033[?1006h"
+	tMouseOff = "\033[?1006l\033[?1000l"
+)
+
+// inputEvent is a key press or mouse click read from the terminal in raw
+// mode. key is the name of a special key or the typed character, it is
+// empty for mouse events.
+type inputEvent struct {
+	key string
+
+	mouse  bool
+	button int
+	press  bool
+	// Position of the mouse, 0-based column and line on the screen
+	x, y int
+}
+
+// readInput reads raw input from r and sends it to ch until r fails.
+func readInput(r io.Reader, ch chan<- []byte) {
+	defer close(ch)
+
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			ch <- data
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// parseKeys splits raw terminal input into events. Unknown escape
+// sequences are skipped. An escape sequence cut at the end of data is
+// returned as rest, it is parsed with the next input or by flushKeys if
+// nothing follows.
+func parseKeys(data []byte) (events []inputEvent, rest []byte) {
+	for len(data) > 0 {
+		switch {
+		case data[0] == 0x1b && len(data) < 3 && (len(data) == 1 || data[1] == '[' || data[1] == 'O'):
+			return events, incomplete(data)
+		case data[0] == 0x1b && (data[1] == '[' || data[1] == 'O'):
+			end := 2
+			for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+				end++
+			}
+
+			if end == len(data) {
+				return events, incomplete(data)
+			}
+
+			if key, ok := arrowKeys[data[end]]; ok && end == 2 {
+				events = append(events, inputEvent{key: key})
+			} else if data[2] == '<' && (data[end] == 'M' || data[end] == 'm') {
+				if event, ok := parseMouse(string(data[3:end]), data[end] == 'M'); ok {
+					events = append(events, event)
+				}
+			}
+
+			data = data[end+1:]
+		case data[0] == 0x1b:
+			events = append(events, inputEvent{key: keyEsc})
+			data = data[1:]
+		case data[0] == 0x03:
+			events = append(events, inputEvent{key: keyCtrlC})
+			data = data[1:]
+		case data[0] == '\r' || data[0] == '\n':
+			events = append(events, inputEvent{key: keyEnter})
+			data = data[1:]
+		case data[0] == ' ':
+			events = append(events, inputEvent{key: keySpace})
+			data = data[1:]
+		case data[0] == '\t':
+			events = append(events, inputEvent{key: keyTab})
+			data = data[1:]
+		default:
+			r, size := utf8.DecodeRune(data)
+			events = append(events, inputEvent{key: string(r)})
+			data = data[size:]
+		}
+	}
+
+	return events, nil
+}
+
+// incomplete returns the start of an escape sequence to complete with the
+// next input, sequences that grew too long are dropped.
+func incomplete(data []byte) []byte {
+	if len(data) > maxEscapeLength {
+		return nil
+	}
+
+	return append([]byte(nil), data...)
+}
+
+// flushKeys returns the events of an escape sequence that wasn't completed
+// in time: a lone escape is the ESC key, longer sequences are dropped.
+func flushKeys(rest []byte) []inputEvent {
+	if len(rest) == 1 {
+		return []inputEvent{{key: keyEsc}}
+	}
+
+	return nil
+}
+
+// Final bytes of the arrow key escape sequences
+var arrowKeys = map[byte]string{
+	'A': keyUp,
+	'B': keyDown,
+	'C': keyRight,
+	'D': keyLeft,
+}
+
+// parseMouse parses the parameters of a SGR mouse report ("button;x;y").
+// Motion and wheel events are ignored.
+func parseMouse(params string, press bool) (inputEvent, bool) {
+	parts := strings.Split(params, ";")
+	if len(parts) != 3 {
+		return inputEvent{}, false
+	}
+
+	values := make([]int, 3)
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return inputEvent{}, false
+		}
+
+		values[i] = value
+	}
+
+	// Bit 5 is set for motion and bit 6 for the wheel
+	if values[0]&(32|64) != 0 {
+		return inputEvent{}, false
+	}
+
+	return inputEvent{
+		mouse:  true,
+		button: values[0] & 3,
+		press:  press,
+		x:      values[1] - 1,
+		y:      values[2] - 1,
+	}, true
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		events []inputEvent
+	}{
+		{"keys", "r \t\r", []inputEvent{{key: "r"}, {key: keySpace}, {key: keyTab}, {key: keyEnter}}},
+		{"arrows", "\x1b[A\x1b[B\x1bOC\x1b[D", []inputEvent{{key: keyUp}, {key: keyDown}, {key: keyRight}, {key: keyLeft}}},
+		{"escape and ctrl+c", "\x1bq\x03", []inputEvent{{key: keyEsc}, {key: "q"}, {key: keyCtrlC}}},
+		{"utf-8", "é", []inputEvent{{key: "é"}}},
+		{"press", "\x1b[<0;12;5M", []inputEvent{{mouse: true, button: mouseLeft, press: true, x: 11, y: 4}}},
+		{"release", "\x1b[<2;1;1m", []inputEvent{{mouse: true, button: mouseRight, x: 0, y: 0}}},
+		{"modifiers", "\x1b[<17;3;4M", []inputEvent{{mouse: true, button: mouseMiddle, press: true, x: 2, y: 3}}},
+		{"motion", "\x1b[<32;3;4M", nil},
+		{"wheel", "\x1b[<64;3;4M\x1b[<65;3;4M", nil},
+		{"unknown sequence", "\x1b[5~x", []inputEvent{{key: "x"}}},
+		{"invalid report", "\x1b[<0;1M", nil},
+	}
+
+	for _, test := range tests {
+		events, rest := parseKeys([]byte(test.data))
+		if !reflect.DeepEqual(events, test.events) || rest != nil {
+			t.Errorf("Expected %+v for %s, but got %+v and the rest %q", test.events, test.name, events, rest)
+		}
+	}
+}
+
+func TestParseKeysSplit(t *testing.T) {
+	click := inputEvent{mouse: true, button: mouseLeft, press: true, x: 1, y: 4}
+
+	tests := []struct {
+		name   string
+		chunks []string
+		events []inputEvent
+	}{
+		{"lone escape", []string{"a\x1b", "[A"}, []inputEvent{{key: "a"}, {key: keyUp}}},
+		{"escape and bracket", []string{"\x1b[", "B"}, []inputEvent{{key: keyDown}}},
+		{"mouse report", []string{"\x1b[<0;2", ";5M"}, []inputEvent{click}},
+		{"three reads", []string{"\x1b", "[<0;", "2;5Mq"}, []inputEvent{click, {key: "q"}}},
+	}
+
+	for _, test := range tests {
+		var events []inputEvent
+		var pending []byte
+
+		for _, chunk := range test.chunks {
+			var parsed []inputEvent
+			parsed, pending = parseKeys(append(pending, chunk...))
+			events = append(events, parsed...)
+		}
+
+		if !reflect.DeepEqual(events, test.events) || pending != nil {
+			t.Errorf("Expected %+v for the split %s, but got %+v and the rest %q", test.events, test.name, events, pending)
+		}
+	}
+
+	// The ESC key is a lone escape that nothing follows
+	if _, rest := parseKeys([]byte("\x1b")); !reflect.DeepEqual(flushKeys(rest), []inputEvent{{key: keyEsc}}) {
+		t.Errorf("Expected the ESC key after a lone escape, but got %+v", flushKeys(rest))
+	}
+
+	if _, rest := parseKeys([]byte("\x1b[<0;1")); flushKeys(rest) != nil {
+		t.Errorf("Expected a cut mouse report to be dropped, but got %+v", flushKeys(rest))
+	}
+}
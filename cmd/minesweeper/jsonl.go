@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// Play modes selected with -mode
+const (
+	modeText  = "text"
+	modeJSONL = "jsonl"
+	modeDuel  = "duel"
+)
+
+// jsonlState is the JSON line written after every command in -mode jsonl.
+// Board uses the cell characters of the bot protocol, mines are only shown
+// once the game is over.
+type jsonlState struct {
+	Type     string   `json:"type"`
+	Rows     int      `json:"rows"`
+	Cols     int      `json:"cols"`
+	Mines    int      `json:"mines"`
+	Flags    int      `json:"flags"`
+	Revealed int      `json:"revealed"`
+	Hidden   int      `json:"hidden"`
+	Moves    int      `json:"moves"`
+	Status   string   `json:"status"`
+	Board    []string `json:"board"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// playJSONL plays a game reading one JSON command per line from r and
+// writing the state as one JSON line to w after every command. The first
+// line is written before any command is read. It returns when the input is
+// closed or a "quit" command is read.
+//
+// Commands are bot moves ({"action":"reveal","row":0,"col":2}) with the
+// actions reveal, flag, chord, state and quit.
+func playJSONL(config *Config, r io.Reader, w io.Writer) error {
+	ctx := context.Background()
+	session := minesweeper.NewSession(newGameBoard(config))
+	encoder := json.NewEncoder(w)
+	moves := 0
+
+	write := func(moveErr error) error {
+		var state *jsonlState
+
+		session.Do(ctx, func(board *minesweeper.Board) error {
+			state = newJSONLState(board, moves)
+			return nil
+		})
+
+		if moveErr != nil {
+			state.Error = moveErr.Error()
+		}
+
+		return encoder.Encode(state)
+	}
+
+	if err := write(nil); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var move botMove
+		if err := json.Unmarshal([]byte(line), &move); err != nil {
+			if err := write(fmt.Errorf("invalid command %q: %w", line, err)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		action := strings.ToLower(move.Action)
+		if action == "quit" || action == "q" {
+			return nil
+		}
+
+		moveErr := applyJSONLMove(ctx, session, action, &move)
+		if moveErr == nil && action != "state" {
+			moves++
+		}
+
+		if err := write(moveErr); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// applyJSONLMove plays a move of -mode jsonl.
+func applyJSONLMove(ctx context.Context, session *minesweeper.Session, action string, move *botMove) error {
+	if action == "state" {
+		return nil
+	}
+
+	row, col, err := move.cell()
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "reveal", "r":
+		_, err = session.Reveal(ctx, row, col)
+	case "flag", "f":
+		err = session.ToggleFlag(ctx, row, col)
+	case "chord", "d":
+		_, err = session.Chord(ctx, row, col)
+	default:
+		err = fmt.Errorf("unknown action %q, use reveal, flag, chord, state or quit", action)
+	}
+
+	return err
+}
+
+// newJSONLState builds the state of the board as seen by the player.
+func newJSONLState(board *minesweeper.Board, moves int) *jsonlState {
+	view := board.PlayerView()
+
+	return &jsonlState{
+		Type:     "state",
+		Rows:     view.Rows,
+		Cols:     view.Cols,
+		Mines:    view.NumMines,
+		Flags:    view.Flags,
+		Revealed: view.Revealed,
+		Hidden:   view.Hidden,
+		Moves:    moves,
+		Status:   view.Status.String(),
+		Board:    view.Lines(),
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// readStates decodes the JSON lines written by playJSONL.
+func readStates(t *testing.T, output string) []jsonlState {
+	t.Helper()
+
+	var states []jsonlState
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		var state jsonlState
+		if err := json.Unmarshal(scanner.Bytes(), &state); err != nil {
+			t.Fatalf("Expected a JSON line, but got %q: %v", scanner.Text(), err)
+		}
+
+		states = append(states, state)
+	}
+
+	return states
+}
+
+func TestPlayJSONL(t *testing.T) {
+	args := []string{"-rows", "3", "-cols", "3", "-mines", "1", "-seed", "1"}
+
+	// The mine and a number next to it on the board of the game
+	board := newGameBoard(parseFlags(args))
+
+	var mine, number string
+	for r := 0; r < board.Rows; r++ {
+		for c := 0; c < board.Cols; c++ {
+			cell := fmt.Sprintf(`"row":%d,"col":%d`, r, c)
+			if board.Cells[r][c].IsMine {
+				mine = cell
+			} else if board.Cells[r][c].MinesAround > 0 {
+				number = cell
+			}
+		}
+	}
+
+	input := strings.Join([]string{
+		`{"action":"reveal",` + number + `}`,
+		``,
+		`{"action":"flag","row":0}`,
+		`not json`,
+		`{"action":"jump",` + mine + `}`,
+		`{"action":"state"}`,
+		`{"action":"reveal",` + mine + `}`,
+	}, "\n")
+
+	var out strings.Builder
+	if err := playJSONL(parseFlags(args), strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	states := readStates(t, out.String())
+	if len(states) != 7 {
+		t.Fatalf("Expected the first state and one per command, but got %d lines:\n%s", len(states), out.String())
+	}
+
+	first := states[0]
+	if first.Type != "state" || first.Status != "playing" || first.Moves != 0 || first.Hidden != 9 || strings.Join(first.Board, "") != "........." {
+		t.Errorf("Expected a hidden board before the first command, but got %+v", first)
+	}
+
+	tests := []struct {
+		moves  int
+		status string
+		error  string
+	}{
+		{1, "playing", ""},
+		{1, "playing", "row and col are required"},
+		{1, "playing", "invalid command"},
+		{1, "playing", "unknown action"},
+		{1, "playing", ""},
+		{2, "lost", ""},
+	}
+
+	for i, test := range tests {
+		state := states[i+1]
+
+		if state.Moves != test.moves || state.Status != test.status {
+			t.Errorf("Expected %d moves and %s after line %d, but got %d and %s", test.moves, test.status, i+1, state.Moves, state.Status)
+		}
+
+		if (test.error == "") != (state.Error == "") || !strings.Contains(state.Error, test.error) {
+			t.Errorf("Expected the error %q after line %d, but got %q", test.error, i+1, state.Error)
+		}
+
+		// Mines are only shown once the game is over
+		if hasMine := strings.Contains(strings.Join(state.Board, ""), "*"); hasMine != (test.status != "playing") {
+			t.Errorf("Expected mines only after the game, but got %q after line %d", state.Board, i+1)
+		}
+	}
+}
+
+func TestPlayJSONLQuit(t *testing.T) {
+	var out strings.Builder
+
+	input := `{"action":"quit"}` + "\n" + `{"action":"reveal","row":0,"col":0}` + "\n"
+	if err := playJSONL(parseFlags([]string{"-rows", "3", "-cols", "3", "-mines", "1", "-seed", "1"}), strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if states := readStates(t, out.String()); len(states) != 1 || states[0].Moves != 0 {
+		t.Errorf("Expected only the first state, but got:\n%s", out.String())
+	}
+
+	// Closed input ends the game after the first state
+	out.Reset()
+	if err := playJSONL(parseFlags([]string{"-rows", "3", "-cols", "3", "-mines", "1", "-seed", "1"}), strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if states := readStates(t, out.String()); len(states) != 1 {
+		t.Errorf("Expected only the first state, but got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,613 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// Letters of the codes of shared boards, without I and O which look like
+// numbers
+const shareCodeLetters = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// lobby hosts games played over telnet or nc. Every connection gets the
+// text interface and picks a board of its own or shares one with friends.
+type lobby struct {
+	mu      sync.Mutex
+	online  int
+	shared  map[string]*sharedGame
+	players map[*sharedGame]map[*sharedPlayer]struct{}
+	races   map[string]*raceGame
+	duels   map[string]*duelGame
+}
+
+// sharedGame is a board played by several connections at once.
+type sharedGame struct {
+	code       string
+	difficulty minesweeper.Difficulty
+	seed       int64
+	session    *minesweeper.Session
+	startTime  time.Time
+
+	// team attributes the moves to the players, guarded by the session
+	team *minesweeper.Team
+}
+
+// sharedPlayer is a connection playing a shared board. Every player has
+// their own display options, they are swapped into the board while the
+// session is held.
+type sharedPlayer struct {
+	config  *Config
+	options *minesweeper.DisplayOptions
+	player  *minesweeper.Player
+}
+
+func newLobby() *lobby {
+	return &lobby{
+		shared:  make(map[string]*sharedGame),
+		players: make(map[*sharedGame]map[*sharedPlayer]struct{}),
+		races:   make(map[string]*raceGame),
+		duels:   make(map[string]*duelGame),
+	}
+}
+
+// runLobby accepts connections on addr until the listener fails.
+func runLobby(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	l := newLobby()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go l.serve(conn)
+	}
+}
+
+// serve runs the lobby for a connection until it quits or disconnects.
+func (l *lobby) serve(conn net.Conn) {
+	t := newTelnetConn(conn)
+	defer t.Close()
+
+	t.negotiate(time.Second)
+
+	config := parseFlags(nil)
+	config.in = bufio.NewScanner(t)
+	config.out = t
+	config.size = t.size
+
+	if terminal, ok := t.terminalType(); ok {
+		config.ansi = ansiTerminal(terminal)
+		config.colorDepth = terminalColorDepth(terminal)
+	} else {
+		config.ansi = confirmANSI(config)
+		config.colorDepth = minesweeper.Color16
+	}
+
+	config.clear = config.ansi
+	config.name = askName(config)
+
+	l.mu.Lock()
+	l.online++
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		l.online--
+		l.mu.Unlock()
+	}()
+
+	for l.menu(config) {
+	}
+}
+
+// confirmANSI asks clients that don't report their terminal type whether
+// they show colors. Yes is the default.
+func confirmANSI(config *Config) bool {
+	fmt.Fprintln(config.out, "Welcome to minesweeper!")
+	fmt.Fprintln(config.out, "Is the word \x1b[32mgreen\x1b[0m shown in green? (Y/n)")
+
+	if !config.in.Scan() {
+		return false
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(config.in.Text()))
+	return answer != "n" && answer != "no"
+}
+
+// askName asks for the name shown to the other players of shared boards.
+func askName(config *Config) string {
+	fmt.Fprintln(config.out, "Your name on shared boards:")
+
+	if !config.in.Scan() {
+		return ""
+	}
+
+	return strings.TrimSpace(config.in.Text())
+}
+
+// menu shows the lobby and runs one command. It returns false when the
+// connection quits or is closed.
+func (l *lobby) menu(config *Config) bool {
+	if config.clear {
+		fmt.Fprintln(config.out, dClear)
+	}
+
+	l.printMenu(config)
+
+	if config.message != "" {
+		fmt.Fprintln(config.out, config.message)
+		config.message = ""
+	}
+
+	fmt.Fprintln(config.out, "Enter command: (1-3 = play alone, share <1-3> = play with friends, race <1-3> = race friends, duel <1-3> [ai] = Flags duel, join <code>, q = quit)")
+
+	if !config.in.Scan() {
+		return false
+	}
+
+	line := config.in.Text()
+
+	commands, err := parseLine(line)
+	if err != nil {
+		return true
+	}
+
+	command := commands[0]
+
+	switch command.Action {
+	case "q", "quit", "exit":
+		return false
+	case "share":
+		if len(command.args()) < 1 {
+			err = command.missing("expected a difficulty (1-3)")
+			break
+		}
+
+		var difficulty minesweeper.Difficulty
+		difficulty, err = lobbyDifficulty(command.args()[0])
+		if err == nil {
+			l.playShared(config, l.share(difficulty))
+		}
+	case "race":
+		if len(command.args()) < 1 {
+			err = command.missing("expected a difficulty (1-3)")
+			break
+		}
+
+		var difficulty minesweeper.Difficulty
+		difficulty, err = lobbyDifficulty(command.args()[0])
+		if err == nil {
+			l.playRace(config, l.newRace(difficulty))
+		}
+	case "duel":
+		args := command.args()
+		if len(args) < 1 {
+			err = command.missing("expected a difficulty (1-3)")
+			break
+		}
+
+		computer := len(args) > 1 && strings.EqualFold(args[1].text, "ai")
+		if len(args) > 1 && !computer {
+			err = errorAt(args[1], "unknown opponent %q, use ai to duel the computer", args[1].text)
+			break
+		}
+
+		var difficulty minesweeper.Difficulty
+		difficulty, err = lobbyDifficulty(args[0])
+		if err == nil {
+			l.playLobbyDuel(config, l.newDuel(difficulty, computer), computer)
+		}
+	case "join":
+		if len(command.args()) < 1 {
+			err = command.missing("expected the code of a shared board, race or duel")
+			break
+		}
+
+		code := command.args()[0]
+		if g, ok := l.game(code.text); ok {
+			l.playShared(config, g)
+			break
+		}
+
+		if rg, ok := l.race(code.text); ok {
+			l.playRace(config, rg)
+			break
+		}
+
+		if dg, ok := l.duelGame(code.text); ok {
+			l.playLobbyDuel(config, dg, false)
+			break
+		}
+
+		err = errorAt(code, "no shared board, race or duel with the code %q", code.text)
+	default:
+		var difficulty minesweeper.Difficulty
+		difficulty, err = lobbyDifficulty(command.tokens[0])
+		if err == nil {
+			playAlone(config, difficulty)
+		}
+	}
+
+	if err != nil {
+		config.message = formatError(line, err)
+	}
+
+	return true
+}
+
+func (l *lobby) printMenu(config *Config) {
+	l.mu.Lock()
+	online := l.online
+	games := make([]*sharedGame, 0, len(l.shared))
+	players := make(map[*sharedGame]int, len(l.shared))
+	for _, g := range l.shared {
+		games = append(games, g)
+		players[g] = len(l.players[g])
+	}
+
+	races := make([]*raceGame, 0, len(l.races))
+	for _, rg := range l.races {
+		races = append(races, rg)
+	}
+
+	duels := make([]*duelGame, 0, len(l.duels))
+	for _, dg := range l.duels {
+		duels = append(duels, dg)
+	}
+	l.mu.Unlock()
+
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].startTime.Before(games[j].startTime)
+	})
+
+	sort.Slice(races, func(i, j int) bool {
+		return races[i].startTime.Before(races[j].startTime)
+	})
+
+	sort.Slice(duels, func(i, j int) bool {
+		return duels[i].startTime.Before(duels[j].startTime)
+	})
+
+	fmt.Fprintf(config.out, "Minesweeper lobby, %d online\n", online)
+	fmt.Fprintln(config.out)
+
+	for i, d := range minesweeper.Difficulties {
+		fmt.Fprintf(config.out, "%d = %s\n", i+1, d)
+	}
+
+	fmt.Fprintln(config.out)
+
+	if len(games) == 0 {
+		fmt.Fprintln(config.out, "No shared boards, start one with share <1-3>")
+	} else {
+		fmt.Fprintln(config.out, "Shared boards:")
+		for _, g := range games {
+			fmt.Fprintf(config.out, "  %s  %s, %d playing\n", g.code, g.difficulty.Name, players[g])
+		}
+	}
+
+	if len(races) > 0 {
+		fmt.Fprintln(config.out)
+		fmt.Fprintln(config.out, "Races:")
+	}
+
+	for _, rg := range races {
+		fmt.Fprintf(config.out, "  %s  %s, first click %s, board version %d\n", rg.code, rg.difficulty.Name, rg.race.FirstClick, rg.race.Version)
+
+		// The progress bars of the racers, like in the race
+		rg.mu.Lock()
+		printRacers(&Config{out: config.out, race: rg.race})
+		rg.mu.Unlock()
+	}
+
+	if len(duels) > 0 {
+		fmt.Fprintln(config.out)
+		fmt.Fprintln(config.out, "Duels waiting for an opponent:")
+	}
+
+	for _, dg := range duels {
+		dg.mu.Lock()
+		fmt.Fprintf(config.out, "  %s  %s, %s\n", dg.code, dg.difficulty.Name, dg.duel.Players()[0].Name)
+		dg.mu.Unlock()
+	}
+
+	fmt.Fprintln(config.out)
+}
+
+// lobbyDifficulty parses the number or name of a difficulty preset.
+func lobbyDifficulty(t token) (minesweeper.Difficulty, error) {
+	if n, err := strconv.Atoi(t.text); err == nil && n >= 1 && n <= len(minesweeper.Difficulties) {
+		return minesweeper.Difficulties[n-1], nil
+	}
+
+	if d, ok := minesweeper.DifficultyByName(t.text); ok {
+		return d, nil
+	}
+
+	return minesweeper.Difficulty{}, errorAt(t, "unknown command %q, use 1-%d, share, race, duel, join or q", t.text, len(minesweeper.Difficulties))
+}
+
+// useDifficulty sets the board of the config to the preset.
+func useDifficulty(config *Config, difficulty minesweeper.Difficulty, seed int64) {
+	config.rows, config.cols, config.mines = difficulty.Rows, difficulty.Cols, difficulty.Mines
+	config.difficulty = difficulty.Name
+	config.seed = seed
+	config.lastInput = ""
+}
+
+// playAlone plays a game on a board of the connection's own.
+func playAlone(config *Config, difficulty minesweeper.Difficulty) {
+	useDifficulty(config, difficulty, time.Now().UnixNano())
+	playGame(config)
+}
+
+// share creates a shared board.
+func (l *lobby) share(difficulty minesweeper.Difficulty) *sharedGame {
+	seed := time.Now().UnixNano()
+
+	board := minesweeper.NewBoard(difficulty.Rows, difficulty.Cols, difficulty.Mines, &minesweeper.BoardOptions{Seed: seed}, nil)
+
+	g := &sharedGame{
+		difficulty: difficulty,
+		seed:       seed,
+		session:    minesweeper.NewSession(board),
+		startTime:  time.Now(),
+		team:       minesweeper.NewTeam(board),
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	g.code = l.newCode()
+	l.shared[g.code] = g
+	l.players[g] = make(map[*sharedPlayer]struct{})
+
+	return g
+}
+
+// newCode returns a code that no shared board, race or duel has. The mutex must
+// be held.
+func (l *lobby) newCode() string {
+	for {
+		b := make([]byte, 4)
+		for i := range b {
+			b[i] = shareCodeLetters[rand.Intn(len(shareCodeLetters))]
+		}
+
+		code := string(b)
+		if l.shared[code] == nil && l.races[code] == nil && l.duels[code] == nil {
+			return code
+		}
+	}
+}
+
+// game returns the shared board with the code.
+func (l *lobby) game(code string) (*sharedGame, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	g, ok := l.shared[strings.ToUpper(code)]
+	return g, ok
+}
+
+// join adds the player to the board. It returns false if the board was
+// deleted since it was looked up.
+func (l *lobby) join(g *sharedGame, p *sharedPlayer) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	players, ok := l.players[g]
+	if !ok {
+		return false
+	}
+
+	players[p] = struct{}{}
+	return true
+}
+
+// leave removes the player from the board. The board is deleted when the
+// last player leaves.
+func (l *lobby) leave(g *sharedGame, p *sharedPlayer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.players[g], p)
+	if len(l.players[g]) == 0 {
+		delete(l.players, g)
+		delete(l.shared, g.code)
+	}
+}
+
+// others returns the players of the board other than p.
+func (l *lobby) others(g *sharedGame, p *sharedPlayer) []*sharedPlayer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var others []*sharedPlayer
+	for other := range l.players[g] {
+		if other != p {
+			others = append(others, other)
+		}
+	}
+
+	return others
+}
+
+// playShared plays a shared board until the game ends or the player quits.
+// Moves of one player redraw the screens of the others. The connections
+// queue the output, so a player who doesn't read doesn't hold the board.
+func (l *lobby) playShared(config *Config, g *sharedGame) {
+	ctx := context.Background()
+
+	useDifficulty(config, g.difficulty, g.seed)
+
+	// A question would hold the board until it is answered
+	config.noPrompts = true
+	defer func() {
+		config.noPrompts = false
+	}()
+
+	p := &sharedPlayer{config: config, options: newDisplayOptions(config)}
+
+	joined := false
+	g.session.Do(ctx, func(board *minesweeper.Board) error {
+		if joined = l.join(g, p); joined {
+			p.player = g.team.Join(config.name)
+			config.team = g.team
+
+			// Show the new player to the others
+			if board.Status() == minesweeper.StatusPlaying {
+				l.redraw(board, g, p)
+			}
+		}
+
+		return nil
+	})
+
+	if !joined {
+		config.message = fmt.Sprintf("The shared board %s is closed", g.code)
+		return
+	}
+
+	// Leave while holding the session, so no redraw is written after the
+	// lobby is shown again
+	defer g.session.Do(ctx, func(board *minesweeper.Board) error {
+		l.leave(g, p)
+		g.team.Leave(p.player)
+		config.team = nil
+
+		if board.Status() == minesweeper.StatusPlaying {
+			l.redraw(board, g, p)
+		}
+
+		return nil
+	})
+
+	config.message = fmt.Sprintf("Shared board %s, friends join with: join %s", g.code, g.code)
+
+	for {
+		over := false
+		g.session.Do(ctx, func(board *minesweeper.Board) error {
+			board.DisplayOptions = p.options
+
+			if board.Status() != minesweeper.StatusPlaying {
+				over = true
+				printSharedEnd(board, g, config)
+				return nil
+			}
+
+			drawGame(board, config)
+			return nil
+		})
+
+		if !config.in.Scan() {
+			return
+		}
+
+		if over {
+			return
+		}
+
+		line := config.in.Text()
+
+		// The help waits for ENTER outside of the session
+		if isHelp(line) {
+			if config.clear {
+				fmt.Fprintln(config.out, dClear)
+			}
+
+			config.noPrompts = false
+			printHelp(config)
+			config.noPrompts = true
+			continue
+		}
+
+		// Revealing the board would end the game for the whole team. Other
+		// commands only change the player's own display options.
+		if isCheat(line) {
+			config.message = "cheat is not allowed on a shared board"
+			continue
+		}
+
+		quit := false
+		g.session.Do(ctx, func(board *minesweeper.Board) error {
+			// The game ended while the player was typing
+			if board.Status() != minesweeper.StatusPlaying {
+				over = true
+				return nil
+			}
+
+			board.DisplayOptions = p.options
+			before := board.CellsRevealed() + board.FlagsCount()
+
+			err := g.team.Play(p.player, func() error {
+				_, manualQuit, err := handleInput(line, board, config)
+				quit = manualQuit
+				return err
+			})
+
+			if err != nil {
+				config.message = err.Error()
+			}
+
+			if board.CellsRevealed()+board.FlagsCount() != before || board.Status() != minesweeper.StatusPlaying {
+				l.redraw(board, g, p)
+				board.DisplayOptions = p.options
+			}
+
+			return nil
+		})
+
+		if quit || over {
+			return
+		}
+	}
+}
+
+// redraw draws the board for the other players of a shared board. The
+// session must be held.
+func (l *lobby) redraw(board *minesweeper.Board, g *sharedGame, p *sharedPlayer) {
+	for _, other := range l.others(g, p) {
+		board.DisplayOptions = other.options
+
+		if board.Status() != minesweeper.StatusPlaying {
+			printSharedEnd(board, g, other.config)
+		} else {
+			drawGame(board, other.config)
+		}
+	}
+}
+
+// printSharedEnd prints the statistics of a shared board that is over.
+func printSharedEnd(board *minesweeper.Board, g *sharedGame, config *Config) {
+	printStatistics(board, g.startTime, config, true)
+	fmt.Fprintln(config.out)
+	fmt.Fprintln(config.out, "Just press ENTER(↵) to return to the lobby ...")
+}
+
+// isHelp reports whether the line is a help command.
+func isHelp(line string) bool {
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "h", "help", "imlost":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLobbyMenu(t *testing.T) {
+	l := newLobby()
+
+	c, server := newPipeClient(t)
+
+	done := make(chan struct{})
+	go func() {
+		l.serve(server)
+		close(done)
+	}()
+
+	// A client without telnet answers the color question and its name
+	c.send(t, "n\nalice\n")
+	c.waitFor(t, "Minesweeper lobby, 1 online")
+
+	c.send(t, "join ZZZZ\n")
+	out := c.waitFor(t, `no shared board, race or duel with the code "ZZZZ"`)
+
+	if i := strings.Index(out, "(Y/n)"); i < 0 || strings.Contains(out[i:], "\x1b[") {
+		t.Errorf("Expected no escape codes after the client said it has no colors")
+	}
+
+	c.send(t, "q\n")
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the connection to be closed after q")
+	}
+
+	if l.online != 0 {
+		t.Errorf("Expected nobody online, but got %d", l.online)
+	}
+}
+
+func TestLobbySlowPlayer(t *testing.T) {
+	l := newLobby()
+
+	alice, server := newPipeClient(t)
+	go l.serve(server)
+
+	alice.send(t, "n\nalice\nshare 1\n")
+	out := alice.waitFor(t, "friends join with: join ")
+
+	code := regexp.MustCompile(`join ([A-Z]{4})`).FindStringSubmatch(out)[1]
+
+	// Bob joins the board but never reads what is written to him
+	server, bob := net.Pipe()
+	defer bob.Close()
+
+	go l.serve(server)
+	go bob.Write([]byte("n\nbob\njoin " + code + "\n"))
+
+	alice.waitFor(t, "bob")
+
+	// The redraw of bob's screen doesn't hold alice's move
+	alice.send(t, "f 1 1\n")
+	alice.waitFor(t, "Flags:  1")
+
+	alice.send(t, "f 1 2\n")
+	alice.waitFor(t, "Flags:  2")
+}
+
+func TestLobbySharedCheat(t *testing.T) {
+	l := newLobby()
+
+	c, server := newPipeClient(t)
+	go l.serve(server)
+
+	c.send(t, "n\nalice\nshare 1\n")
+	c.waitFor(t, "friends join with: join ")
+
+	c.send(t, "f 1 1; cheat\n")
+	c.waitFor(t, "cheat is not allowed on a shared board")
+
+	// The board is still played and the other commands of the line are not
+	if out := c.output(); strings.Contains(out, "Flags:  1") || strings.Contains(out, "ENTER") {
+		t.Errorf("Expected the line to be rejected, but got %q", out)
+	}
+}
+
+func TestLobbySharedBoard(t *testing.T) {
+	l := newLobby()
+
+	alice, server := newPipeClient(t)
+	go l.serve(server)
+
+	alice.send(t, "n\nalice\nshare beginner\n")
+	out := alice.waitFor(t, "friends join with: join ")
+
+	code := regexp.MustCompile(`join ([A-Z]{4})`).FindStringSubmatch(out)[1]
+
+	bob, server := newPipeClient(t)
+	go l.serve(server)
+
+	// Codes are not case sensitive
+	bob.send(t, "n\nbob\njoin "+strings.ToLower(code)+"\n")
+	bob.waitFor(t, "friends join with: join "+code)
+	alice.waitFor(t, "bob")
+
+	// The lobby lists the board with its players
+	carol, server := newPipeClient(t)
+	go l.serve(server)
+
+	carol.send(t, "n\ncarol\n")
+	carol.waitFor(t, "  "+code+"  beginner, 2 playing")
+	carol.send(t, "q\n")
+
+	// A move of bob is drawn on the screen of alice
+	bob.send(t, "f 1 1\n")
+	alice.waitFor(t, "Flags:  1")
+
+	// The help waits for ENTER, then bob leaves
+	bob.send(t, "h\n")
+	bob.waitFor(t, "Just press ENTER(↵) to continue ...")
+	bob.send(t, "\nq\n")
+	bob.waitFor(t, "Minesweeper lobby, 2 online")
+
+	if g, ok := l.game(code); !ok || len(l.others(g, nil)) != 1 {
+		t.Fatalf("Expected alice to be left on the board")
+	}
+
+	// The board is deleted when the last player leaves
+	alice.send(t, "q\n")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := l.game(code); !ok {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the board to be deleted after everybody left")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	bob.send(t, "join "+code+"\n")
+	bob.waitFor(t, `no shared board, race or duel with the code "`+code+`"`)
+}
+
+func TestLobbyDifficulty(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"1", "beginner"},
+		{"3", "expert"},
+		{"Intermediate", "intermediate"},
+	}
+
+	for _, test := range tests {
+		difficulty, err := lobbyDifficulty(token{text: test.text})
+		if err != nil || difficulty.Name != test.want {
+			t.Errorf("Expected %s for %q, but got %s (%v)", test.want, test.text, difficulty.Name, err)
+		}
+	}
+
+	for _, text := range []string{"0", "4", "hard"} {
+		if _, err := lobbyDifficulty(token{text: text}); err == nil {
+			t.Errorf("Expected an error for %q", text)
+		}
+	}
+}
@@ -2,14 +2,16 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/TechMDW/minesweeper/internal/scores"
 	"github.com/TechMDW/minesweeper/internal/util"
 	minesweeper "github.com/TechMDW/minesweeper/pkg"
 )
@@ -23,6 +25,9 @@ type Config struct {
 	rows            int
 	cols            int
 	mines           int
+	difficulty      string
+	minDifficulty   float64
+	maxDifficulty   float64
 	footer          bool
 	header          bool
 	seed            int64
@@ -30,6 +35,10 @@ type Config struct {
 	ansi            bool
 	showHelp        bool
 	clear           bool
+	tui             bool
+	viewRows        int
+	viewCols        int
+	minimap         bool
 	topIndex        bool
 	bottomIndex     bool
 	rightIndex      bool
@@ -38,55 +47,166 @@ type Config struct {
 	symbolFlag      string
 	symbolHidden    string
 	symbolSeperator string
-}
-
-type Command struct {
-	Action string
-	Args   []string
+	coordinates     minesweeper.Coordinates
+	theme           minesweeper.Theme
+	colorDepth      minesweeper.ColorDepth
+	bot             string
+	botTimeout      time.Duration
+	script          string
+	mode            string
+	players         []string
+	ai              bool
+
+	// name is the name of the player on shared boards and the leaderboard
+	name string
+	// dataDir is the directory of the leaderboard and the history, games
+	// are only kept if it is set
+	dataDir string
+	// team is set when several players share the board
+	team *minesweeper.Team
+	// race is set when the board is raced against other players
+	race *minesweeper.Race
+	// duel is set when two players duel for the mines of the board
+	duel *minesweeper.Duel
+
+	// Input and output of the text interface, stdin and stdout unless the
+	// game is played over a network connection
+	in  *bufio.Scanner
+	out io.Writer
+	// size returns the size of the terminal
+	size func() (rows, cols int, err error)
+	// noPrompts answers every question with no instead of asking, used by
+	// scripts and shared boards
+	noPrompts bool
+
+	// Last line of commands, repeated by "."
+	lastInput string
+	// Message shown below the board on the next draw, like a parse error
+	message string
+
+	// Clicks of the current game and whether cheat was used, for the
+	// leaderboard. recorded is set once the game is kept, rank is the rank
+	// of a win.
+	clicks   int
+	cheated  bool
+	recorded bool
+	rank     int
 }
 
 // printHelp prints the help message.
-func printHelp() {
-	fmt.Println("r <row> <col> = reveal cell at position (row, col)")
+func printHelp(config *Config) {
+	if config.coordinates == minesweeper.CoordinatesChess {
+		fmt.Fprintln(config.out, "r <cell> [cell ...] = reveal cells, a cell is a column letter and a row number (r C7)")
+
+		fmt.Fprintln(config.out)
+
+		fmt.Fprintln(config.out, "f <cell> [cell ...] = flag cells (f AB12)")
+
+		fmt.Fprintln(config.out)
+
+		fmt.Fprintln(config.out, "goto <cell> = move the view to the cell")
+	} else {
+		fmt.Fprintln(config.out, "r <row> <col> = reveal cell at position (row, col)")
+
+		fmt.Fprintln(config.out)
+
+		fmt.Fprintln(config.out, "c <col> <row> = reveal cell at position (col, row)")
+
+		fmt.Fprintln(config.out)
+
+		fmt.Fprintln(config.out, "f <row> <col> = flag cell at position (row, col)")
+
+		fmt.Fprintln(config.out)
+
+		fmt.Fprintln(config.out, "goto <row> <col> = move the view to position (row, col)")
+	}
+
+	fmt.Fprintln(config.out)
 
-	fmt.Println()
+	fmt.Fprintln(config.out, "up/down/left/right [n] = move the view n rows or columns")
 
-	fmt.Println("c <col> <row> = reveal cell at position (col, row)")
+	fmt.Fprintln(config.out)
 
-	fmt.Println()
+	if config.coordinates == minesweeper.CoordinatesChess {
+		fmt.Fprintln(config.out, "C7:E9 = all cells of a rectangle (f C7:E9)")
+	} else {
+		fmt.Fprintln(config.out, "4-9 or 4:9 = a range of rows or columns (r 3 4-9, f 2:4 5:8)")
+	}
+
+	fmt.Fprintln(config.out)
+
+	fmt.Fprintln(config.out, "<command>; <command> = run several commands, . = repeat the last line")
 
-	fmt.Println("f <row> <col> = flag cell at position (row, col)")
+	fmt.Fprintln(config.out)
 
-	fmt.Println()
+	fmt.Fprintln(config.out, "minimap = show or hide the minimap")
 
-	fmt.Println("header = hide header (show only board + footer)")
+	fmt.Fprintln(config.out)
 
-	fmt.Println()
+	fmt.Fprintln(config.out, "header = hide header (show only board + footer)")
 
-	fmt.Println("footer = hide footer (show only board + header)")
+	fmt.Fprintln(config.out)
 
-	fmt.Println()
+	fmt.Fprintln(config.out, "footer = hide footer (show only board + header)")
 
-	fmt.Println("h/help/imlost = show this help")
+	fmt.Fprintln(config.out)
 
-	fmt.Println()
+	fmt.Fprintln(config.out, "h/help/imlost = show this help")
 
-	fmt.Println("q/quit/exit = quit game")
+	fmt.Fprintln(config.out)
+
+	fmt.Fprintln(config.out, "q/quit/exit = quit game")
+
+	fmt.Fprintln(config.out)
+
+	if config.noPrompts {
+		return
+	}
+
+	fmt.Fprintln(config.out, "Just press ENTER(↵) to continue ...")
+
+	config.in.Scan()
+}
+
+// printStandings prints the race from the first to the last racer, the
+// winner once every racer finished.
+func printStandings(config *Config) {
+	fmt.Fprintln(config.out)
+
+	if winner := config.race.Winner(); winner != nil {
+		fmt.Fprintf(config.out, "%s won the race!\n", winner.Name)
+	} else {
+		fmt.Fprintln(config.out, "The race goes on, waiting for the other racers ...")
+	}
 
-	fmt.Println()
+	for i, r := range config.race.Standings() {
+		line := fmt.Sprintf("  %d. %s: %.1f%% in %s", i+1, r.Name, r.Progress()*100, util.FormatDuration(r.Time()))
 
-	fmt.Println("Just press ENTER(↵) to continue ...")
+		switch {
+		case r.Board.Status() == minesweeper.StatusWon:
+			line = fmt.Sprintf("  %d. %s: cleared in %s", i+1, r.Name, util.FormatDuration(r.Time()))
+		case r.Board.Status() == minesweeper.StatusLost:
+			line += ", hit a mine"
+		case r.Left:
+			line += ", left"
+		}
 
-	fmt.Scanln()
+		fmt.Fprintln(config.out, line)
+	}
 }
 
 func printFooter(config *Config) {
 	if !config.footer {
-		fmt.Println("")
+		fmt.Fprintln(config.out)
+		return
+	}
+
+	if config.coordinates == minesweeper.CoordinatesChess {
+		fmt.Fprintln(config.out, "Enter command: (r <cell> = reveal, f <cell> = flag, h = help)")
 		return
 	}
 
-	fmt.Println("Enter command: (r <row> <col> = reveal, f <row> <col> = flag, h = help)")
+	fmt.Fprintln(config.out, "Enter command: (r <row> <col> = reveal, f <row> <col> = flag, h = help)")
 }
 
 func printHeader(board *minesweeper.Board, config *Config) {
@@ -97,20 +217,75 @@ func printHeader(board *minesweeper.Board, config *Config) {
 	percentageDone := board.RevealedPercentage()
 
 	if config.header {
-		fmt.Println("Cells left: ", board.CellsNonRevealed())
-		fmt.Println("Flags: ", board.FlagsCount())
-		fmt.Println("Mines: ", board.NumMines)
-		fmt.Println(" ", util.FormatPercentageBar(percentageDone, config.cols*3-2))
+		fmt.Fprintln(config.out, "Cells left: ", board.CellsNonRevealed())
+		fmt.Fprintln(config.out, "Flags: ", board.FlagsCount())
+		fmt.Fprintln(config.out, "Mines: ", board.NumMines)
+		fmt.Fprintln(config.out, " ", util.FormatPercentageBar(percentageDone, barWidth(board)))
+	}
+
+	if config.team != nil {
+		printPlayers(board, config)
+	}
+
+	if config.race != nil {
+		printRacers(config)
+	}
+}
+
+// printPlayers prints the players of the team in the colors of their
+// cursors with the number of cells they cleared.
+func printPlayers(board *minesweeper.Board, config *Config) {
+	var players []string
+	for _, p := range config.team.Players() {
+		if !p.Left {
+			name := minesweeper.Style{Foreground: p.Color, Bold: true}.Paint(p.Name, config.colorDepth)
+			players = append(players, fmt.Sprintf("%s %d", name, p.Revealed))
+		}
+	}
+
+	board.Fprintln(config.out, "Players:", strings.Join(players, ", "))
+}
+
+// printRacers prints the progress of the racers, one line each.
+func printRacers(config *Config) {
+	racers := config.race.Racers()
+
+	width := 0
+	for _, r := range racers {
+		if len(r.Name) > width {
+			width = len(r.Name)
+		}
+	}
+
+	for _, r := range racers {
+		line := fmt.Sprintf("  %-*s %s", width, r.Name, util.FormatPercentageBar(r.Progress(), 20))
+
+		switch {
+		case r.Board.Status() == minesweeper.StatusWon:
+			line += " cleared in " + util.FormatDuration(r.Time())
+		case r.Board.Status() == minesweeper.StatusLost:
+			line += " hit a mine"
+		case r.Left:
+			line += " left"
+		}
+
+		fmt.Fprintln(config.out, line)
 	}
 }
 
-func parseFlags() *Config {
+// parseFlags parses the command line arguments into a config. The defaults
+// are used for nil.
+func parseFlags(args []string) *Config {
 	flags := flag.NewFlagSet("minesweeper", flag.ExitOnError)
 
 	// Game/Board options
 	rows := flags.Int("rows", 10, "Number of rows")
 	cols := flags.Int("cols", 10, "Number of columns")
 	mines := flags.Int("mines", 10, "Number of mines")
+	difficulty := flags.String("difficulty", minesweeper.DifficultyCustom, "Difficulty preset (beginner, intermediate, expert or custom)")
+	density := flags.Float64("density", 0, "Share of cells that are mines (0-1), alternative to -mines")
+	minDifficulty := flags.Float64("minDifficulty", 0, "Minimum difficulty rating of the generated board (0 = no limit)")
+	maxDifficulty := flags.Float64("maxDifficulty", 0, "Maximum difficulty rating of the generated board (0 = no limit)")
 	seed := flags.Int64("seed", time.Now().UnixNano(), "Seed for random number generator")
 	header := flags.Bool("header", true, "Show header")
 	footer := flags.Bool("footer", true, "Show footer")
@@ -122,6 +297,9 @@ func parseFlags() *Config {
 	symbolFlag := flags.String("symbolFlag", minesweeper.SymbolFlag, "Symbol to use for flags")
 	symbolHidden := flags.String("symbolHidden", minesweeper.SymbolHidden, "Symbol to use for hidden cells")
 	symbolSeperator := flags.String("symbolSeperator", minesweeper.SymbolSeperator, "Symbol to use for seperating cells")
+	coords := flags.String("coords", minesweeper.CoordinatesNumeric.String(), "Coordinates of cells: numeric (r <row> <col>) or chess (r C7, columns are letters)")
+	theme := flags.String("theme", minesweeper.ThemeClassic.Name, "Color theme (classic, high-contrast, colorblind) or path to a JSON theme file")
+	colors := flags.String("colors", "auto", "Colors supported by the terminal (16, 256, truecolor or auto)")
 	topIndex := flags.Bool("topIndex", true, "Show top index")
 	bottomIndex := flags.Bool("bottomIndex", false, "Show bottom index")
 	rightIndex := flags.Bool("rightIndex", false, "Show right index")
@@ -130,22 +308,135 @@ func parseFlags() *Config {
 	// Default/Debug options
 	showHelp := flags.Bool("help", false, "Show help")
 	clear := flags.Bool("clear", true, "Automatically clear the screen")
+	mode := flags.String("mode", modeText, "Play mode: text, jsonl (one JSON command per input line and one JSON state per output line) or duel (two players take turns to find the mines)")
+	fullScreen := flags.Bool("tui", false, "Play in full-screen mode with a cursor")
+	players := flags.String("players", "", "Names of the players sharing the terminal in full-screen mode or a duel, separated by commas (Tab passes the turn)")
+	ai := flags.Bool("ai", false, "Duel against the computer")
+	name := flags.String("name", defaultName(), "Your name on the leaderboard")
+	viewRows := flags.Int("viewRows", 0, "Number of rows shown at once (0 = fit the terminal)")
+	viewCols := flags.Int("viewCols", 0, "Number of columns shown at once (0 = fit the terminal)")
+	minimap := flags.Bool("minimap", true, "Show a minimap when the board does not fit")
+
+	// Script options
+	script := flags.String("script", "", "Play the commands of a file (- for stdin) without prompts, the exit status is 0 for a win and 1 for a loss")
+
+	// Bot options
+	bot := flags.String("bot", "", "Command of an external program that plays the game over stdin/stdout (JSON lines)")
+	botTimeout := flags.Duration("botTimeout", 5*time.Second, "Time the bot gets to answer each move")
 
-	flags.Parse(os.Args[1:])
+	flags.Parse(args)
 
 	if *startIndex < 0 {
 		startIndex = util.IntPtr(0)
 	}
 
+	// Track which flags were set explicitly to validate the combination
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	if set["density"] && set["mines"] {
+		exitWithError("-density and -mines can't be used together")
+	}
+
+	if strings.ToLower(*difficulty) != minesweeper.DifficultyCustom {
+		preset, ok := minesweeper.DifficultyByName(*difficulty)
+		if !ok {
+			exitWithError(fmt.Sprintf("unknown difficulty %q, use beginner, intermediate, expert or custom", *difficulty))
+		}
+
+		if set["rows"] || set["cols"] || set["mines"] || set["density"] {
+			exitWithError(fmt.Sprintf("-difficulty %s sets the board size and mines, use -difficulty custom to choose them yourself", preset.Name))
+		}
+
+		rows, cols, mines = &preset.Rows, &preset.Cols, &preset.Mines
+	}
+
+	if set["density"] {
+		if *density <= 0 || *density >= 1 {
+			exitWithError(fmt.Sprintf("-density must be between 0 and 1, got %g", *density))
+		}
+
+		mines = util.IntPtr(minesweeper.MinesForDensity(*rows, *cols, *density))
+	}
+
+	if err := minesweeper.ValidateBoard(*rows, *cols, *mines); err != nil {
+		exitWithError(err.Error())
+	}
+
+	if *minDifficulty < 0 || *maxDifficulty < 0 {
+		exitWithError("-minDifficulty and -maxDifficulty can't be negative")
+	}
+
+	if *minDifficulty > 0 && *maxDifficulty > 0 && *minDifficulty > *maxDifficulty {
+		exitWithError(fmt.Sprintf("-minDifficulty %g is above -maxDifficulty %g", *minDifficulty, *maxDifficulty))
+	}
+
+	if *mode != modeText && *mode != modeJSONL && *mode != modeDuel {
+		exitWithError(fmt.Sprintf("unknown mode %q, use text, jsonl or duel", *mode))
+	}
+
+	if *mode == modeDuel && *fullScreen {
+		exitWithError("-mode duel is played without -tui")
+	}
+
+	if *ai && *mode != modeDuel {
+		exitWithError("-ai needs -mode duel")
+	}
+
+	var playerNames []string
+	if *players != "" {
+		if !*fullScreen && *mode != modeDuel {
+			exitWithError("-players needs -tui or -mode duel")
+		}
+
+		for _, name := range strings.Split(*players, ",") {
+			playerNames = append(playerNames, strings.TrimSpace(name))
+		}
+	}
+
+	coordinates, err := minesweeper.ParseCoordinates(*coords)
+	if err != nil {
+		exitWithError(err.Error())
+	}
+
+	selectedTheme, ok := minesweeper.ThemeByName(*theme)
+	if !ok {
+		loaded, err := minesweeper.LoadTheme(*theme)
+		if err != nil {
+			exitWithError(fmt.Sprintf("-theme %s is not a built-in theme and could not be loaded: %v", *theme, err))
+		}
+
+		selectedTheme = loaded
+	}
+
+	colorDepth := minesweeper.DetectColorDepth()
+	if *colors != "auto" {
+		depth, err := minesweeper.ParseColorDepth(*colors)
+		if err != nil {
+			exitWithError(err.Error())
+		}
+
+		colorDepth = depth
+	}
+
 	return &Config{
 		rows:            *rows,
 		cols:            *cols,
 		mines:           *mines,
+		difficulty:      minesweeper.DifficultyOf(*rows, *cols, *mines),
+		minDifficulty:   *minDifficulty,
+		maxDifficulty:   *maxDifficulty,
 		seed:            *seed,
 		startIndex:      *startIndex,
 		ansi:            *ansi,
 		showHelp:        *showHelp,
 		clear:           *clear,
+		tui:             *fullScreen,
+		viewRows:        *viewRows,
+		viewCols:        *viewCols,
+		minimap:         *minimap,
 		header:          *header,
 		footer:          *footer,
 		topIndex:        *topIndex,
@@ -156,54 +447,102 @@ func parseFlags() *Config {
 		symbolFlag:      *symbolFlag,
 		symbolHidden:    *symbolHidden,
 		symbolSeperator: *symbolSeperator,
+		coordinates:     coordinates,
+		theme:           selectedTheme,
+		colorDepth:      colorDepth,
+		bot:             *bot,
+		botTimeout:      *botTimeout,
+		script:          *script,
+		mode:            *mode,
+		players:         playerNames,
+		ai:              *ai,
+		name:            *name,
+		noPrompts:       *script != "",
+		in:              bufio.NewScanner(os.Stdin),
+		out:             os.Stdout,
+		size: func() (int, int, error) {
+			return terminalSize(int(os.Stdout.Fd()))
+		},
 	}
 }
 
+// exitWithError prints the error and exits the same way invalid flags do.
+func exitWithError(msg string) {
+	fmt.Fprintln(os.Stderr, "minesweeper:", msg)
+	os.Exit(2)
+}
+
 func printStatistics(board *minesweeper.Board, startTime time.Time, config *Config, manualQuit bool) {
 	gameDuration := time.Since(startTime)
 	cellNonRevealed := board.CellsNonRevealed()
 	cellsRevealed := board.CellsRevealed()
 	flagCount := board.FlagsCount()
 	percentage := board.RevealedPercentage()
+	rating := minesweeper.Rate(board)
 
 	if config.clear {
-		fmt.Println(dClear)
+		fmt.Fprintln(config.out, dClear)
 	}
 
-	board.Display(true)
+	display(board, config, true)
 
-	fmt.Println()
+	fmt.Fprintln(config.out)
 
-	if percentage == 1 {
-		board.Printf("\x1b[32m%s\x1b[0m\n", "You won!")
-	} else {
-		board.Printf("\x1b[31m%s\x1b[0m\n", "You lost!")
+	switch board.Status() {
+	case minesweeper.StatusWon:
+		board.Fprintf(config.out, "\x1b[32m%s\x1b[0m\n", "You won!")
+	case minesweeper.StatusLost:
+		board.Fprintf(config.out, "\x1b[31m%s\x1b[0m\n", "You lost!")
+	default:
+		board.Fprintf(config.out, "\x1b[33m%s\x1b[0m\n", "Game not finished.")
+	}
+
+	fmt.Fprintf(config.out, "You completed %d/%d cells in %s (%.2f%%)\n", cellsRevealed, cellsRevealed+cellNonRevealed, util.FormatDuration(gameDuration), percentage*100)
+	fmt.Fprintln(config.out, "Seed:", config.seed)
+	fmt.Fprintln(config.out)
+	fmt.Fprintln(config.out, "Difficulty:", config.difficulty)
+	fmt.Fprintf(config.out, "Size: %d X %d\n", config.rows, config.cols)
+	fmt.Fprintln(config.out, "Amount of cells:", config.rows*config.cols)
+	fmt.Fprintln(config.out, "Mines:", config.mines)
+	fmt.Fprintln(config.out, "Cells revealed:", cellsRevealed)
+	fmt.Fprintln(config.out, "Cells left:", cellNonRevealed)
+	fmt.Fprintln(config.out, "Flags:", flagCount)
+	fmt.Fprintf(config.out, "Difficulty rating: %.1f (3BV: %d, openings: %d, guesses: %d, hardest rule: %s)\n", rating.Score, rating.BV3, rating.Openings, rating.Guesses, rating.HardestRule)
+
+	if config.team != nil {
+		fmt.Fprintln(config.out, "Players:")
+
+		for _, p := range config.team.Players() {
+			line := fmt.Sprintf("  %s: %d cells cleared, %d flags, %d moves", p.Name, p.Revealed, p.Flags, p.Moves)
+			if p.HitMine {
+				line += ", hit a mine"
+			}
+
+			fmt.Fprintln(config.out, line)
+		}
 	}
 
-	fmt.Printf("You completed %d/%d cells in %s (%.2f%%)\n", cellsRevealed, cellsRevealed+cellNonRevealed, util.FormatDuration(gameDuration), percentage*100)
-	fmt.Println("Seed:", config.seed)
-	fmt.Println("")
-	fmt.Printf("Size: %d X %d\n", config.rows, config.cols)
-	fmt.Println("Amount of cells:", config.rows*config.cols)
-	fmt.Println("Mines:", config.mines)
-	fmt.Println("Cells revealed:", cellsRevealed)
-	fmt.Println("Cells left:", cellNonRevealed)
-	fmt.Println("Flags:", flagCount)
+	if config.race != nil {
+		printStandings(config)
+	}
+
+	if board.Status() != minesweeper.StatusPlaying {
+		printRecord(board, gameDuration, config)
+	}
 
 	if manualQuit {
 		return
 	}
 
 	// Allow user to restart or quit
-	fmt.Println("Enter command: (r = retry same seed, rn = retry new seed, q = quit)")
+	fmt.Fprintln(config.out, "Enter command: (r = retry same seed, rn = retry new seed, q = quit)")
 
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
-		fmt.Println("Error reading input.")
+	if !config.in.Scan() {
+		fmt.Fprintln(config.out, "Error reading input.")
 		return
 	}
 
-	input := scanner.Text()
+	input := config.in.Text()
 	command := strings.ToLower(input)
 
 	switch command {
@@ -215,178 +554,188 @@ func printStatistics(board *minesweeper.Board, startTime time.Time, config *Conf
 	case "q", "quit", "exit":
 		return
 	default:
-		fmt.Println("BYE!")
+		fmt.Fprintln(config.out, "BYE!")
 	}
 }
 
-func parseInput(input string) (*Command, error) {
-	parts := strings.Fields(input)
-	if len(parts) == 0 {
-		return nil, fmt.Errorf("empty input")
-	}
-	return &Command{
-		Action: strings.ToLower(parts[0]),
-		Args:   parts[1:],
-	}, nil
+// inputError is an error of a command in a line of input.
+type inputError struct {
+	line string
+	err  error
 }
 
-func handleInput(input string, board *minesweeper.Board, config *Config) (gameOver, manualQuit bool) {
-	command, err := parseInput(input)
+func (e *inputError) Error() string {
+	return formatError(e.line, e.err)
+}
+
+// handleInput runs the commands of a line. "." repeats the last line.
+// It stops at the first command that fails and returns its error.
+func handleInput(input string, board *minesweeper.Board, config *Config) (gameOver, manualQuit bool, err error) {
+	if strings.TrimSpace(input) == "." {
+		if config.lastInput == "" {
+			return false, false, errors.New("No command to repeat")
+		}
+
+		input = config.lastInput
+	}
+
+	commands, err := parseLine(input)
 	if err != nil {
-		fmt.Println("Invalid input format.")
-		return
+		return false, false, &inputError{line: input, err: err}
 	}
 
+	config.lastInput = input
+
+	for _, command := range commands {
+		gameOver, manualQuit, err = handleCommand(command, board, config)
+		if err != nil {
+			return false, false, &inputError{line: input, err: err}
+		}
+
+		if gameOver {
+			return
+		}
+	}
+
+	return
+}
+
+func handleCommand(command *Command, board *minesweeper.Board, config *Config) (gameOver, manualQuit bool, err error) {
 	switch command.Action {
 	case "r":
-		if handleReveal(command.Args, false, board, config) {
-			gameOver = true
-		}
+		gameOver, err = handleReveal(command, false, board, config)
 	case "c":
-		if handleReveal(command.Args, true, board, config) {
-			gameOver = true
-		}
+		gameOver, err = handleReveal(command, true, board, config)
 	case "f", "fr", "rf":
-		handleFlag(command.Args, false, board, config)
+		err = handleFlag(command, false, board, config)
 	case "fc", "cf":
-		handleFlag(command.Args, true, board, config)
+		err = handleFlag(command, true, board, config)
 	case "h", "help", "imlost":
 		if config.clear {
-			fmt.Println(dClear)
+			fmt.Fprintln(config.out, dClear)
 		}
 
-		printHelp()
+		printHelp(config)
 	case "footer":
 		config.footer = !config.footer
 	case "header":
 		config.header = !config.header
 	case "ansi":
 		board.DisplayOptions.ANSI = util.BoolPtr(!*board.DisplayOptions.ANSI)
+	case "goto":
+		err = handleGoto(command, board)
+	case "up", "down", "left", "right":
+		err = handlePan(command, board)
+	case "minimap":
+		config.minimap = !config.minimap
 	case "start":
+		if len(command.args()) < 1 {
+			return false, false, command.missing("expected the start index")
+		}
+
 		var sIndex int
-		if _, err := fmt.Sscanf(strings.Join(command.Args, " "), "start %d", &sIndex); err != nil && err != io.EOF {
-			fmt.Println("Invalid input format.")
+		sIndex, err = parseInt(command.args()[0], "start index")
+		if err != nil {
 			return
 		}
 
 		board.DisplayOptions.StartIndex = util.IntPtr(sIndex)
 	case "cheat":
+		config.cheated = true
 		board.RevealAll()
 		gameOver = true
 	case "q", "quit", "exit":
 		gameOver = true
 		manualQuit = true
 	default:
-		board.Printf("\x1b[41;37m%s\x1b[0m\n", "Invalid command!")
+		err = errorAt(command.tokens[0], "unknown command %q, type h for help", command.tokens[0].text)
 	}
 
 	return
 }
 
-func handleReveal(args []string, inverted bool, board *minesweeper.Board, config *Config) (gameOver bool) {
-	if len(args) < 2 {
-		fmt.Println("Invalid input format")
-		return
+// confirm asks the player a yes or no question, no is the default. Scripts
+// and shared boards never ask and always answer no.
+func confirm(config *Config, question string) bool {
+	if config.noPrompts {
+		return false
 	}
 
-	x, err := strconv.Atoi(args[0])
-	if err != nil {
-		fmt.Println("Invalid input format")
-		return
-	}
+	fmt.Fprintln(config.out, question)
 
-	y := make([]int, 0, len(args)-1)
-	for _, arg := range args[1:] {
-		num, err := strconv.Atoi(arg)
-		if err != nil {
-			fmt.Println("Invalid input format")
-			return
-		}
-		y = append(y, num)
+	if !config.in.Scan() {
+		fmt.Fprintln(config.out, "Error reading input.")
+		return false
 	}
 
-	// Convert to 0-based index
-	x -= *board.DisplayOptions.StartIndex
-
-	for _, yi := range y {
-		yi -= *board.DisplayOptions.StartIndex
-
-		// Determine the correct order of arguments for the board.Reveal function
-		row, col := x, yi
-		if inverted {
-			row, col = col, row
-		}
-
-		if board.IsFlagged(row, col) {
-			board.Println("Are you sure you want to reveal a flagged cell? (y/N)")
+	return strings.ToLower(config.in.Text()) == "y"
+}
 
-			scanner := bufio.NewScanner(os.Stdin)
+// errInvalidInput is returned for empty input
+var errInvalidInput = errors.New("Invalid input format")
 
-			if !scanner.Scan() {
-				fmt.Println("Error reading input.")
-				return
-			}
+func handleReveal(command *Command, inverted bool, board *minesweeper.Board, config *Config) (gameOver bool, err error) {
+	cells, err := parseCells(command, inverted, board)
+	if err != nil {
+		return false, err
+	}
 
-			input := scanner.Text()
+	for _, cell := range cells {
+		row, col := cell.Row, cell.Col
 
-			if strings.ToLower(input) != "y" {
-				continue
-			}
+		if board.IsFlagged(row, col) && !confirm(config, fmt.Sprintf("Are you sure you want to reveal the flagged cell %s? (y/N)", board.FormatCell(row, col))) {
+			continue
 		}
 
+		config.clicks++
 		if board.Reveal(row, col) {
-			gameOver = true
-			break
+			return true, nil
 		}
 	}
 
-	return
+	return false, nil
 }
 
-func handleFlag(args []string, inverted bool, board *minesweeper.Board, config *Config) {
-	if len(args) < 2 {
-		fmt.Println("Invalid input format")
-		return
-	}
-
-	x, err := strconv.Atoi(args[0])
+func handleFlag(command *Command, inverted bool, board *minesweeper.Board, config *Config) error {
+	cells, err := parseCells(command, inverted, board)
 	if err != nil {
-		fmt.Println("Invalid input format")
-		return
+		return err
 	}
 
-	y := make([]int, 0, len(args)-1)
-	for _, arg := range args[1:] {
-		num, err := strconv.Atoi(arg)
-		if err != nil {
-			fmt.Println("Invalid input format")
-			return
+	// Revealed cells can't be flagged, ranges may include them
+	for _, cell := range cells {
+		if !board.Cells[cell.Row][cell.Col].IsRevealed {
+			config.clicks++
+			board.ToggleFlag(cell.Row, cell.Col)
 		}
-		y = append(y, num)
 	}
 
-	// Convert to 0-based index
-	x -= *board.DisplayOptions.StartIndex
-
-	for _, yi := range y {
-		yi -= *board.DisplayOptions.StartIndex
+	return nil
+}
 
-		// Determine the correct order of arguments for the board.Reveal function
-		row, col := x, yi
-		if inverted {
-			row, col = col, row
-		}
+// newGameBoard creates a board from the config and starts counting the
+// clicks of the new game.
+func newGameBoard(config *Config) *minesweeper.Board {
+	config.clicks, config.cheated, config.recorded, config.rank = 0, false, false, 0
 
-		board.ToggleFlag(row, col)
+	boardOptions := &minesweeper.BoardOptions{
+		Seed:          config.seed,
+		MinDifficulty: config.minDifficulty,
+		MaxDifficulty: config.maxDifficulty,
 	}
-}
 
-func playGame(config *Config) {
-	boardOptions := &minesweeper.BoardOptions{
-		Seed: config.seed,
+	board, err := minesweeper.NewBoardContext(context.Background(), config.rows, config.cols, config.mines, boardOptions, newDisplayOptions(config))
+	if err != nil {
+		config.message = fmt.Sprintf("No board in the difficulty range was found in time, this one is rated %.1f", minesweeper.Rate(board).Score)
 	}
 
-	displayOptions := &minesweeper.DisplayOptions{
+	return board
+}
+
+// newDisplayOptions returns the display options of the config. The options
+// point into the config, so toggling them changes the config.
+func newDisplayOptions(config *Config) *minesweeper.DisplayOptions {
+	return &minesweeper.DisplayOptions{
 		StartIndex: &config.startIndex,
 		ANSI:       &config.ansi,
 
@@ -395,53 +744,133 @@ func playGame(config *Config) {
 		SymbolHidden:    &config.symbolHidden,
 		SymbolSeperator: &config.symbolSeperator,
 
+		Coordinates: &config.coordinates,
+
+		Theme:      &config.theme,
+		ColorDepth: &config.colorDepth,
+
+		Viewport: &minesweeper.Viewport{},
+		Minimap:  &config.minimap,
+
 		TopIndex:    &config.topIndex,
 		LeftIndex:   &config.leftIndex,
 		RightIndex:  &config.rightIndex,
 		BottomIndex: &config.bottomIndex,
 	}
+}
+
+// display prints the board to the output of the config. If showMines is
+// true the end view with all mines is shown, otherwise the player view.
+func display(board *minesweeper.Board, config *Config, showMines bool) {
+	view := board.PlayerView()
+	if showMines {
+		view = board.EndView()
+	}
+
+	renderer := minesweeper.NewRenderer(*board.DisplayOptions.ANSI)
+	if *board.DisplayOptions.ANSI && config.team != nil {
+		renderer = minesweeper.ANSIRenderer{Cursors: config.team.Cursors()}
+	}
+
+	// Claimed mines in the colors of the players
+	if *board.DisplayOptions.ANSI && config.duel != nil {
+		renderer = minesweeper.ANSIRenderer{Cursors: config.duel.Claims()}
+	}
+
+	renderer.Render(config.out, view, board.DisplayOptions)
+}
+
+// drawGame draws the screen of a game in progress: the header, the board,
+// the message of the last command and the footer.
+func drawGame(board *minesweeper.Board, config *Config) {
+	if config.clear {
+		fmt.Fprintln(config.out, dClear)
+	}
+
+	// Header, footer, input and the empty line of the clear
+	reservedLines := 7
+	if config.team != nil {
+		reservedLines++
+	}
+
+	if config.race != nil {
+		reservedLines += len(config.race.Racers())
+	}
+
+	fitViewport(board, config, reservedLines)
+
+	printHeader(board, config)
+
+	display(board, config, false)
+
+	if config.message != "" {
+		fmt.Fprintln(config.out, config.message)
+		config.message = ""
+	}
 
-	board := minesweeper.NewBoard(config.rows, config.cols, config.mines, boardOptions, displayOptions)
+	printFooter(config)
+}
+
+func playGame(config *Config) {
+	board := newGameBoard(config)
 
 	gameOver := false
 	manualQuit := false
 
-	scanner := bufio.NewScanner(os.Stdin)
-
 	startTime := time.Now()
 
 	for !gameOver {
-		if config.clear {
-			fmt.Println(dClear)
+		if board.RevealedPercentage() == 1 {
+			break
 		}
 
-		percentageDone := board.RevealedPercentage()
+		drawGame(board, config)
 
-		if percentageDone == 1 {
+		// Read user input, stop when the input is closed
+		if !config.in.Scan() {
+			fmt.Fprintln(config.out, "Error reading input.")
+			manualQuit = true
 			break
 		}
 
-		printHeader(board, config)
-
-		board.Display(false)
-
-		printFooter(config)
+		input := config.in.Text()
 
-		// Read user input
-		if !scanner.Scan() {
-			fmt.Println("Error reading input.")
-			continue
+		var err error
+		gameOver, manualQuit, err = handleInput(input, board, config)
+		if err != nil {
+			config.message = err.Error()
 		}
-
-		input := scanner.Text()
-		gameOver, manualQuit = handleInput(input, board, config)
 	}
 
 	printStatistics(board, startTime, config, manualQuit)
 }
 
 func main() {
-	config := parseFlags()
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := runServe(os.Args[2:]); err != nil {
+			exitWithError(err.Error())
+		}
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "scores" {
+		if err := runScores(os.Args[2:], os.Stdout); err != nil {
+			exitWithError(err.Error())
+		}
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		if err := runStats(os.Args[2:], os.Stdout); err != nil {
+			exitWithError(err.Error())
+		}
+
+		return
+	}
+
+	config := parseFlags(os.Args[1:])
 
 	if config.showHelp {
 		fmt.Println("Usage: minesweeper [OPTIONS]")
@@ -450,5 +879,38 @@ func main() {
 		return
 	}
 
+	if config.mode == modeJSONL {
+		if err := playJSONL(config, os.Stdin, os.Stdout); err != nil {
+			exitWithError(err.Error())
+		}
+
+		return
+	}
+
+	if config.mode == modeDuel {
+		playDuel(config)
+		return
+	}
+
+	if config.script != "" {
+		os.Exit(playScript(config))
+	}
+
+	if config.bot != "" {
+		playBot(config)
+		return
+	}
+
+	// Games played by hand are kept in the history, wins on the leaderboard
+	config.dataDir, _ = scores.Dir()
+
+	if config.tui {
+		if err := playTUI(config); err != nil {
+			exitWithError(err.Error())
+		}
+
+		return
+	}
+
 	playGame(config)
 }
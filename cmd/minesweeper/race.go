@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// raceGame is a race of connections on identical boards. The mutex guards
+// the race, the boards of the racers and their screens, every move redraws
+// the progress of the others.
+type raceGame struct {
+	code       string
+	difficulty minesweeper.Difficulty
+	startTime  time.Time
+
+	mu     sync.Mutex
+	race   *minesweeper.Race
+	racers map[*racePlayer]struct{}
+	closed bool
+}
+
+// racePlayer is a connection playing a race.
+type racePlayer struct {
+	config *Config
+	racer  *minesweeper.Racer
+}
+
+// newRace creates a race.
+func (l *lobby) newRace(difficulty minesweeper.Difficulty) *raceGame {
+	rg := &raceGame{
+		difficulty: difficulty,
+		startTime:  time.Now(),
+		race:       minesweeper.NewRace(difficulty.Rows, difficulty.Cols, difficulty.Mines, time.Now().UnixNano()),
+		racers:     make(map[*racePlayer]struct{}),
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rg.code = l.newCode()
+	l.races[rg.code] = rg
+
+	return rg
+}
+
+// race returns the race with the code.
+func (l *lobby) race(code string) (*raceGame, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rg, ok := l.races[strings.ToUpper(code)]
+	return rg, ok
+}
+
+// playRace plays a board of the race until the player returns to the lobby.
+// The race goes on until every racer finished or left, the standings are
+// redrawn until then.
+func (l *lobby) playRace(config *Config, rg *raceGame) {
+	useDifficulty(config, rg.difficulty, rg.race.Seed)
+
+	// A question would hold the race until it is answered
+	config.noPrompts = true
+	defer func() {
+		config.noPrompts = false
+	}()
+
+	p := &racePlayer{config: config}
+
+	rg.mu.Lock()
+	if rg.closed {
+		rg.mu.Unlock()
+		config.message = fmt.Sprintf("The race %s is closed", rg.code)
+		return
+	}
+
+	racer, err := rg.race.Join(config.name, newDisplayOptions(config))
+	if err != nil {
+		rg.mu.Unlock()
+		config.message = fmt.Sprintf("Can't join the race %s: %v", rg.code, err)
+		return
+	}
+
+	p.racer = racer
+	rg.racers[p] = struct{}{}
+	config.race = rg.race
+	rg.redraw(p)
+	rg.mu.Unlock()
+
+	defer l.leaveRace(rg, p)
+
+	board := p.racer.Board
+
+	config.message = fmt.Sprintf("Race %s, friends join with: join %s", rg.code, rg.code)
+
+	for {
+		rg.mu.Lock()
+		over := p.racer.Finished()
+		if over {
+			printRaceEnd(p)
+		} else {
+			drawGame(board, config)
+		}
+		rg.mu.Unlock()
+
+		if !config.in.Scan() || over {
+			return
+		}
+
+		line := config.in.Text()
+
+		// The help waits for ENTER outside of the race
+		if isHelp(line) {
+			if config.clear {
+				fmt.Fprintln(config.out, dClear)
+			}
+
+			config.noPrompts = false
+			printHelp(config)
+			config.noPrompts = true
+			continue
+		}
+
+		// Revealing the board would win any race
+		if isCheat(line) {
+			config.message = "cheat is not allowed in a race"
+			continue
+		}
+
+		rg.mu.Lock()
+		before := board.CellsRevealed()
+
+		_, quit, err := handleInput(line, board, config)
+		if err != nil {
+			config.message = err.Error()
+		}
+
+		// Flags don't change the progress
+		if board.CellsRevealed() != before {
+			rg.redraw(p)
+		}
+		rg.mu.Unlock()
+
+		if quit {
+			return
+		}
+	}
+}
+
+// leaveRace takes the player out of the race, the result of the racer stays.
+// The race is deleted when the last player leaves.
+func (l *lobby) leaveRace(rg *raceGame, p *racePlayer) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	delete(rg.racers, p)
+	rg.race.Leave(p.racer)
+	p.config.race = nil
+
+	if len(rg.racers) > 0 {
+		rg.redraw(p)
+		return
+	}
+
+	rg.closed = true
+
+	l.mu.Lock()
+	delete(l.races, rg.code)
+	l.mu.Unlock()
+}
+
+// redraw draws the screens of the racers other than p. The mutex must be
+// held.
+func (rg *raceGame) redraw(p *racePlayer) {
+	for other := range rg.racers {
+		if other == p {
+			continue
+		}
+
+		if other.racer.Finished() {
+			printRaceEnd(other)
+		} else {
+			drawGame(other.racer.Board, other.config)
+		}
+	}
+}
+
+// printRaceEnd prints the statistics of a racer whose game is over with the
+// standings of the race.
+func printRaceEnd(p *racePlayer) {
+	printStatistics(p.racer.Board, time.Now().Add(-p.racer.Time()), p.config, true)
+	fmt.Fprintln(p.config.out)
+	fmt.Fprintln(p.config.out, "Just press ENTER(↵) to return to the lobby ...")
+}
+
+// isCheat reports whether the line has a cheat command.
+func isCheat(line string) bool {
+	commands, err := parseLine(line)
+	if err != nil {
+		return false
+	}
+
+	for _, command := range commands {
+		if command.Action == "cheat" {
+			return true
+		}
+	}
+
+	return false
+}
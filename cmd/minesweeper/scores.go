@@ -0,0 +1,259 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/TechMDW/minesweeper/internal/scores"
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// defaultName returns the name of the user running the game, or "player".
+func defaultName() string {
+	u, err := user.Current()
+	if err != nil || u.Username == "" {
+		return "player"
+	}
+
+	// Windows user names start with the domain
+	name := u.Username
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return name
+}
+
+// keepsScores reports whether the games of the config are kept: games
+// played alone with a data directory set.
+func keepsScores(config *Config) bool {
+	return config.dataDir != "" && config.team == nil && config.race == nil && config.duel == nil
+}
+
+// recordGame keeps the finished game in the history and a win on the
+// leaderboard, the rank of the win is set in the config. Games played with
+// cheat are left out, a game is only kept once.
+func recordGame(board *minesweeper.Board, duration time.Duration, config *Config) error {
+	if !keepsScores(config) || config.cheated || config.recorded || board.Status() == minesweeper.StatusPlaying {
+		return nil
+	}
+
+	config.recorded = true
+
+	settings := scores.SettingsOf(board.Rows, board.Cols, board.NumMines)
+	won := board.Status() == minesweeper.StatusWon
+	now := time.Now()
+
+	game := scores.Game{
+		Settings: settings,
+		Won:      won,
+		Time:     duration,
+		Cleared:  board.RevealedPercentage(),
+		Clicks:   config.clicks,
+		Date:     now,
+	}
+
+	view := board.PlayerView()
+	for r, row := range view.Cells {
+		for c, cell := range row {
+			if cell.State == minesweeper.CellExploded {
+				game.Hit = &scores.Cell{Row: r, Col: c}
+			}
+		}
+	}
+
+	if _, err := scores.AddGame(filepath.Join(config.dataDir, scores.HistoryFileName), game); err != nil {
+		return err
+	}
+
+	if !won {
+		return nil
+	}
+
+	bv3 := float64(board.BV3())
+
+	entry := scores.Entry{
+		Name:     config.name,
+		Time:     duration,
+		Seed:     board.BoardOptions.Seed,
+		Settings: settings,
+		Date:     now,
+	}
+
+	if duration > 0 {
+		entry.BV3PerSecond = bv3 / duration.Seconds()
+	}
+
+	if config.clicks > 0 {
+		entry.Efficiency = bv3 / float64(config.clicks)
+	}
+
+	_, rank, err := scores.Record(filepath.Join(config.dataDir, scores.FileName), entry)
+	if err != nil {
+		return err
+	}
+
+	config.rank = rank
+
+	return nil
+}
+
+// printRecord keeps the finished game and prints the record of its
+// configuration, with the best results after a win.
+func printRecord(board *minesweeper.Board, duration time.Duration, config *Config) {
+	if !keepsScores(config) {
+		return
+	}
+
+	fmt.Fprintln(config.out)
+
+	if config.cheated {
+		fmt.Fprintln(config.out, "Games played with cheat are not kept on the leaderboard and in the statistics.")
+		return
+	}
+
+	if err := recordGame(board, duration, config); err != nil {
+		fmt.Fprintln(config.out, "Could not keep the game:", err)
+		return
+	}
+
+	settings := scores.SettingsOf(board.Rows, board.Cols, board.NumMines)
+
+	h, err := scores.LoadHistory(filepath.Join(config.dataDir, scores.HistoryFileName))
+	if err != nil {
+		fmt.Fprintln(config.out, "Could not read the statistics:", err)
+		return
+	}
+
+	stats := h.StatsOf(settings)
+	fmt.Fprintf(config.out, "Won %d of %d games (%.1f%%), streak: %d (best: %d)\n", stats.Won, stats.Played, stats.WinRate()*100, stats.CurrentStreak, stats.BestStreak)
+
+	if board.Status() != minesweeper.StatusWon {
+		return
+	}
+
+	l, err := scores.Load(filepath.Join(config.dataDir, scores.FileName))
+	if err != nil {
+		fmt.Fprintln(config.out, "Could not read the leaderboard:", err)
+		return
+	}
+
+	fmt.Fprintln(config.out)
+	printTop(config.out, l, settings, scores.DefaultTop, config.rank)
+
+	if kept := len(l.Top(settings, 0)); config.rank > kept {
+		fmt.Fprintf(config.out, "Your result is #%d, only the %d fastest are kept.\n", config.rank, kept)
+	} else if config.rank > scores.DefaultTop {
+		fmt.Fprintf(config.out, "Your result is #%d of %d.\n", config.rank, kept)
+	}
+}
+
+// printTop prints the n best results with the settings, the result at rank
+// is marked.
+func printTop(out io.Writer, l *scores.Leaderboard, settings scores.Settings, n, rank int) {
+	top := l.Top(settings, n)
+
+	fmt.Fprintf(out, "Leaderboard %s:\n", settings)
+
+	if len(top) == 0 {
+		fmt.Fprintln(out, "  No games won yet")
+		return
+	}
+
+	width := len("Name")
+	for _, e := range top {
+		if len(e.Name) > width {
+			width = len(e.Name)
+		}
+	}
+
+	fmt.Fprintf(out, "     #  %-*s  %10s  %6s  %10s  %-20s  %s\n", width, "Name", "Time", "3BV/s", "Efficiency", "Seed", "Date")
+
+	for i, e := range top {
+		marker := " "
+		if i+1 == rank {
+			marker = ">"
+		}
+
+		fmt.Fprintf(out, "  %s %2d  %-*s  %9.3fs  %6.2f  %9.0f%%  %-20d  %s\n", marker, i+1, width, e.Name, e.Time.Seconds(), e.BV3PerSecond, e.Efficiency*100, e.Seed, e.Date.Format("2006-01-02"))
+	}
+}
+
+// runScores runs "minesweeper scores", printing the leaderboard of a
+// configuration or of all configurations with results.
+func runScores(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("minesweeper scores", flag.ExitOnError)
+
+	chosen := settingsFlags(flags)
+	top := flags.Int("top", scores.DefaultTop, "Number of results shown per configuration (0 = all)")
+
+	flags.Parse(args)
+
+	one, err := chosen()
+	if err != nil {
+		return err
+	}
+
+	path, err := scores.Path()
+	if err != nil {
+		return err
+	}
+
+	l, err := scores.Load(path)
+	if err != nil {
+		return err
+	}
+
+	settings := l.Settings()
+	if one != nil {
+		settings = []scores.Settings{*one}
+	}
+
+	if len(settings) == 0 {
+		fmt.Fprintln(out, "No games won yet, the leaderboard is kept in", path)
+		return nil
+	}
+
+	for i, s := range settings {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		printTop(out, l, s, *top, 0)
+	}
+
+	return nil
+}
+
+// settingsFlags adds the flags choosing a configuration to the flags. The
+// returned function returns the chosen settings once the flags are parsed,
+// nil for every configuration.
+func settingsFlags(flags *flag.FlagSet) func() (*scores.Settings, error) {
+	difficulty := flags.String("difficulty", "", "Difficulty preset (beginner, intermediate, expert or custom), empty shows every configuration")
+	rows := flags.Int("rows", 10, "Number of rows of the custom board")
+	cols := flags.Int("cols", 10, "Number of columns of the custom board")
+	mines := flags.Int("mines", 10, "Number of mines of the custom board")
+
+	return func() (*scores.Settings, error) {
+		switch name := strings.ToLower(*difficulty); name {
+		case "":
+			return nil, nil
+		case minesweeper.DifficultyCustom:
+			settings := scores.SettingsOf(*rows, *cols, *mines)
+			return &settings, nil
+		default:
+			preset, ok := minesweeper.DifficultyByName(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown difficulty %q, use beginner, intermediate, expert or custom", *difficulty)
+			}
+
+			settings := scores.SettingsOf(preset.Rows, preset.Cols, preset.Mines)
+			return &settings, nil
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// Exit status of a scripted game
+const (
+	exitWon  = 0
+	exitLost = 1
+	// exitUsage is used for invalid flags and scripts that can't be read
+	exitUsage = 2
+	// exitScriptError is used when a command of the script fails
+	exitScriptError = 3
+	// exitUnfinished is used when the script ends or quits before the game is
+	// over, or reveals the board with cheat
+	exitUnfinished = 4
+)
+
+// playScript plays the commands of the script file, one line at a time,
+// and returns the exit status. Empty lines and lines starting with "#" are
+// skipped. Every command is printed followed by the board.
+func playScript(config *Config) int {
+	var input io.Reader = os.Stdin
+	if config.script != "-" {
+		file, err := os.Open(config.script)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "minesweeper:", err)
+			return exitUsage
+		}
+		defer file.Close()
+
+		input = file
+	}
+
+	// Scripts never clear the screen or wait for the player
+	config.clear = false
+
+	board := newGameBoard(config)
+	startTime := time.Now()
+
+	status := exitUnfinished
+
+	scanner := bufio.NewScanner(input)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		fmt.Fprintln(config.out, ">", line)
+
+		gameOver, manualQuit, err := handleInput(line, board, config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "minesweeper: %s:%d:\n%s\n", config.script, lineNumber, err)
+			return exitScriptError
+		}
+
+		if manualQuit {
+			break
+		}
+
+		if gameOver || board.Status() != minesweeper.StatusPlaying {
+			status = exitStatus(board, config)
+			break
+		}
+
+		fitViewport(board, config, 0)
+		printHeader(board, config)
+		display(board, config, false)
+		fmt.Fprintln(config.out)
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, "minesweeper:", err)
+		return exitUsage
+	}
+
+	printStatistics(board, startTime, config, true)
+
+	return status
+}
+
+// exitStatus returns the exit status for the status of the game. A board
+// revealed with cheat was not played to the end, it is unfinished.
+func exitStatus(board *minesweeper.Board, config *Config) int {
+	if config.cheated {
+		return exitUnfinished
+	}
+
+	switch board.Status() {
+	case minesweeper.StatusWon:
+		return exitWon
+	case minesweeper.StatusLost:
+		return exitLost
+	}
+
+	return exitUnfinished
+}
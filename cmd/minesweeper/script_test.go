@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestExitStatus(t *testing.T) {
+	board := minesweeper.NewBoard(3, 3, 1, &minesweeper.BoardOptions{Seed: 1}, nil)
+	config := &Config{}
+
+	if status := exitStatus(board, config); status != exitUnfinished {
+		t.Errorf("Expected %d for a game in progress, but got %d", exitUnfinished, status)
+	}
+
+	// A board revealed with cheat is not a win
+	config.cheated = true
+	board.RevealAll()
+
+	if status := exitStatus(board, config); status != exitUnfinished {
+		t.Errorf("Expected %d after cheat, but got %d", exitUnfinished, status)
+	}
+
+	config.cheated = false
+
+	if status := exitStatus(board, config); status != exitWon {
+		t.Errorf("Expected %d for a won game, but got %d", exitWon, status)
+	}
+}
+
+// blockingReader fails the test when a script waits for the player.
+type blockingReader struct {
+	t *testing.T
+}
+
+func (r blockingReader) Read(p []byte) (int, error) {
+	r.t.Error("Expected the script to never read from the player")
+	return 0, os.ErrClosed
+}
+
+func TestPlayScript(t *testing.T) {
+	args := []string{"-rows", "3", "-cols", "3", "-mines", "1", "-seed", "1", "-header=false"}
+
+	// The mine, the safe cells and the numbers of the board the script
+	// plays, 1-based
+	board := newGameBoard(parseFlags(args))
+
+	var mine, safe, numbers []string
+	for r := 0; r < board.Rows; r++ {
+		for c := 0; c < board.Cols; c++ {
+			cell := fmt.Sprintf("%d %d", r+1, c+1)
+			if board.Cells[r][c].IsMine {
+				mine = append(mine, cell)
+			} else {
+				safe = append(safe, cell)
+			}
+
+			if board.Cells[r][c].MinesAround > 0 {
+				numbers = append(numbers, cell)
+			}
+		}
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		status int
+		want   string
+	}{
+		{"win", "# every safe cell\nr " + strings.Join(safe, "; r ") + "\n", exitWon, "You won!"},
+		{"loss", "r " + numbers[0] + "\n\nr " + mine[0] + "\nr " + numbers[1] + "\n", exitLost, "You lost!"},
+		{"unfinished", "r " + numbers[0] + "\n", exitUnfinished, "Game not finished."},
+		{"quit", "q\nr " + mine[0] + "\n", exitUnfinished, "Game not finished."},
+		// A flagged cell is kept without asking
+		{"flagged", "f " + mine[0] + "\nr " + mine[0] + "\n", exitUnfinished, "Game not finished."},
+		{"error", "r " + numbers[0] + "\nr 9 9\n", exitScriptError, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "game.txt")
+			if err := os.WriteFile(path, []byte(test.script), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			var out strings.Builder
+
+			config := parseFlags(append(args, "-script", path))
+			config.out = &out
+			config.in = bufio.NewScanner(blockingReader{t})
+
+			if status := playScript(config); status != test.status {
+				t.Errorf("Expected the exit status %d, but got %d:\n%s", test.status, status, out.String())
+			}
+
+			if !strings.Contains(out.String(), test.want) {
+				t.Errorf("Expected %q in the output, but got:\n%s", test.want, out.String())
+			}
+
+			if strings.Contains(out.String(), "(y/N)") || strings.Contains(out.String(), "Enter command") {
+				t.Errorf("Expected no prompts, but got:\n%s", out.String())
+			}
+		})
+	}
+}
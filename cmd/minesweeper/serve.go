@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/TechMDW/minesweeper/internal/server"
+)
+
+// runServe runs "minesweeper serve", the HTTP server hosting games and the
+// telnet lobby.
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("minesweeper serve", flag.ExitOnError)
+
+	addr := flags.String("addr", ":8080", "Address of the HTTP server (empty = no HTTP server)")
+	tcp := flags.String("tcp", "", "Address of the lobby for telnet and nc, like :2323 (empty = no lobby)")
+	ttl := flags.Duration("ttl", server.DefaultTTL, "Time a game is kept after its last request")
+	maxGames := flags.Int("maxGames", server.DefaultMaxGames, "Number of games kept at once")
+
+	flags.Parse(args)
+
+	if *addr == "" && *tcp == "" {
+		return errors.New("nothing to serve, set -addr or -tcp")
+	}
+
+	errs := make(chan error, 2)
+
+	if *tcp != "" {
+		fmt.Println("Serving the lobby on", *tcp)
+
+		go func() {
+			errs <- runLobby(*tcp)
+		}()
+	}
+
+	if *addr != "" {
+		srv := &http.Server{
+			Addr: *addr,
+			Handler: server.New(server.Options{
+				TTL:      *ttl,
+				MaxGames: *maxGames,
+			}),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		fmt.Println("Serving games on", *addr)
+
+		go func() {
+			errs <- srv.ListenAndServe()
+		}()
+	}
+
+	return <-errs
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TechMDW/minesweeper/internal/scores"
+	"github.com/TechMDW/minesweeper/internal/util"
+)
+
+// Output formats of "minesweeper stats"
+const (
+	formatText = "text"
+	formatCSV  = "csv"
+	formatJSON = "json"
+)
+
+// Width of the longest bar of a histogram
+const histogramWidth = 30
+
+// Shades of the cells of the mines hit map, from few hits to many
+var hitShades = []string{"░", "▒", "▓", "█"}
+
+// runStats runs "minesweeper stats", printing the lifetime statistics of a
+// configuration or of all configurations with games.
+func runStats(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("minesweeper stats", flag.ExitOnError)
+
+	chosen := settingsFlags(flags)
+	format := flags.String("format", formatText, "Output format: text, csv or json")
+	last := flags.Int("last", 30, "Number of wins in the sparkline of times")
+
+	flags.Parse(args)
+
+	one, err := chosen()
+	if err != nil {
+		return err
+	}
+
+	if *format != formatText && *format != formatCSV && *format != formatJSON {
+		return fmt.Errorf("unknown format %q, use text, csv or json", *format)
+	}
+
+	path, err := scores.HistoryPath()
+	if err != nil {
+		return err
+	}
+
+	h, err := scores.LoadHistory(path)
+	if err != nil {
+		return err
+	}
+
+	all := h.Stats()
+	if one != nil {
+		all = []*scores.Stats{h.StatsOf(*one)}
+	}
+
+	switch *format {
+	case formatCSV:
+		return writeStatsCSV(out, all)
+	case formatJSON:
+		if all == nil {
+			all = []*scores.Stats{}
+		}
+
+		data, err := json.MarshalIndent(all, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+
+	if len(h.Games) == 0 {
+		fmt.Fprintln(out, "No games played yet, the statistics are kept in", path)
+		return nil
+	}
+
+	played, won := 0, 0
+	var total time.Duration
+	for _, s := range h.Stats() {
+		played += s.Played
+		won += s.Won
+		total += s.TotalTime
+	}
+
+	fmt.Fprintf(out, "Played %d games, won %d, total play time %s\n", played, won, total.Round(time.Second))
+
+	for _, s := range all {
+		fmt.Fprintln(out)
+		printStats(out, s, *last)
+	}
+
+	return nil
+}
+
+// printStats prints the statistics of a configuration, with the times of
+// the last wins as a sparkline and the losses as histograms.
+func printStats(out io.Writer, s *scores.Stats, last int) {
+	fmt.Fprintf(out, "%s:\n", s.Settings)
+
+	if s.Played == 0 {
+		fmt.Fprintln(out, "  No games played yet")
+		return
+	}
+
+	fmt.Fprintf(out, "  Played:    %d, won %d (%.1f%%)\n", s.Played, s.Won, s.WinRate()*100)
+	fmt.Fprintf(out, "  Streak:    %d, best %d\n", s.CurrentStreak, s.BestStreak)
+	fmt.Fprintf(out, "  Play time: %s\n", s.TotalTime.Round(time.Second))
+
+	if s.Won > 0 {
+		fmt.Fprintf(out, "  Time:      best %.3fs, average %.3fs\n", s.BestTime.Seconds(), s.AverageTime.Seconds())
+
+		times := s.Times
+		if last > 0 && len(times) > last {
+			times = times[len(times)-last:]
+		}
+
+		seconds := make([]float64, len(times))
+		for i, t := range times {
+			seconds[i] = t.Seconds()
+		}
+
+		fmt.Fprintf(out, "  Last wins: %s (%d, higher is slower)\n", util.FormatSparkline(seconds), len(times))
+	}
+
+	if s.Losses() == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "  Cleared on losses:")
+
+	most := 0
+	for _, n := range s.Cleared {
+		if n > most {
+			most = n
+		}
+	}
+
+	for i, n := range s.Cleared {
+		bucket := 100 / scores.ClearedBuckets
+		bar := strings.Repeat("█", (n*histogramWidth+most-1)/most)
+		fmt.Fprintf(out, "    %3d-%3d%%  %-*s %d\n", i*bucket, (i+1)*bucket, histogramWidth, bar, n)
+	}
+
+	fmt.Fprintln(out, "  Mines hit:")
+	printHits(out, s.Hits)
+}
+
+// printHits prints a map of the board with a shade per cell for the mines
+// hit there, darker is more.
+func printHits(out io.Writer, hits [][]int) {
+	most := 0
+	for _, row := range hits {
+		for _, n := range row {
+			if n > most {
+				most = n
+			}
+		}
+	}
+
+	for _, row := range hits {
+		var b strings.Builder
+		b.WriteString("   ")
+
+		for _, n := range row {
+			shade := "·"
+			if n > 0 {
+				shade = hitShades[(n*len(hitShades)-1)/most]
+			}
+
+			b.WriteString(" " + shade)
+		}
+
+		fmt.Fprintln(out, b.String())
+	}
+}
+
+// writeStatsCSV writes a line per configuration, times are in seconds.
+func writeStatsCSV(out io.Writer, all []*scores.Stats) error {
+	w := csv.NewWriter(out)
+
+	header := []string{"difficulty", "rows", "cols", "mines", "played", "won", "currentStreak", "bestStreak", "bestTime", "averageTime", "totalTime"}
+	for i := 0; i < scores.ClearedBuckets; i++ {
+		bucket := 100 / scores.ClearedBuckets
+		header = append(header, fmt.Sprintf("cleared%d-%d", i*bucket, (i+1)*bucket))
+	}
+
+	w.Write(header)
+
+	seconds := func(d time.Duration) string {
+		return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+	}
+
+	for _, s := range all {
+		record := []string{
+			s.Settings.Difficulty,
+			strconv.Itoa(s.Settings.Rows),
+			strconv.Itoa(s.Settings.Cols),
+			strconv.Itoa(s.Settings.Mines),
+			strconv.Itoa(s.Played),
+			strconv.Itoa(s.Won),
+			strconv.Itoa(s.CurrentStreak),
+			strconv.Itoa(s.BestStreak),
+			seconds(s.BestTime),
+			seconds(s.AverageTime),
+			seconds(s.TotalTime),
+		}
+
+		for _, n := range s.Cleared {
+			record = append(record, strconv.Itoa(n))
+		}
+
+		w.Write(record)
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// Telnet commands (RFC 854)
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetIAC  = 255
+)
+
+// Telnet options asked from the client: the terminal type (RFC 1091) and
+// the window size (RFC 1073)
+const (
+	telnetTerminalType = 24
+	telnetWindowSize   = 31
+
+	terminalTypeIs   = 0
+	terminalTypeSend = 1
+)
+
+// Time a connection may be idle before it is closed, the time a write may
+// take and the output that may wait for a client that doesn't read
+const (
+	telnetIdleTimeout  = 30 * time.Minute
+	telnetWriteTimeout = 10 * time.Second
+	telnetMaxPending   = 1 << 20
+)
+
+var (
+	errNoWindowSize = errors.New("window size not reported")
+	errSlowClient   = errors.New("client doesn't read its output")
+)
+
+// telnetConn is a connection to the lobby. Telnet commands are removed from
+// the input and the terminal type and window size reported by the client
+// are recorded. Clients that don't speak telnet, like nc, are read as is.
+type telnetConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	// lastCR is set after a carriage return, telnet sends "\r\0" for a
+	// carriage return on its own
+	lastCR bool
+
+	mu sync.Mutex
+	// telnet is set once the client answered a telnet command
+	telnet bool
+	// terminal is the terminal type, known is set once the client sent it
+	// or refused to
+	terminal string
+	known    bool
+	rows     int
+	cols     int
+
+	// Output waiting to be sent, guarded by outMu. Writes only queue the
+	// output, so the players of a shared board don't wait for each other.
+	// A client is dropped when its output piles up or a write times out,
+	// outErr is the reason.
+	outMu   sync.Mutex
+	outCond *sync.Cond
+	out     []byte
+	outErr  error
+	closing bool
+	// written is closed when the writer stopped
+	written chan struct{}
+}
+
+func newTelnetConn(conn net.Conn) *telnetConn {
+	t := &telnetConn{conn: conn, r: bufio.NewReader(conn), written: make(chan struct{})}
+	t.outCond = sync.NewCond(&t.outMu)
+
+	go t.writeOutput()
+
+	return t
+}
+
+// negotiate asks the client for its terminal type and window size and
+// waits up to timeout for the answer. Input typed in the meantime is kept.
+func (t *telnetConn) negotiate(timeout time.Duration) {
+	t.queue([]byte{
+		telnetIAC, telnetDO, telnetTerminalType,
+		telnetIAC, telnetDO, telnetWindowSize,
+	})
+
+	t.conn.SetReadDeadline(time.Now().Add(timeout))
+	defer t.conn.SetReadDeadline(time.Time{})
+
+	for !t.terminalKnown() {
+		next, err := t.r.Peek(1)
+		if err != nil || next[0] != telnetIAC {
+			return
+		}
+
+		t.r.ReadByte()
+		if _, _, err := t.command(); err != nil {
+			return
+		}
+	}
+}
+
+func (t *telnetConn) terminalKnown() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.known
+}
+
+// terminalType returns the terminal type reported by the client. ok is
+// false for clients that don't speak telnet.
+func (t *telnetConn) terminalType() (terminal string, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.terminal, t.telnet
+}
+
+// size returns the window size reported by the client.
+func (t *telnetConn) size() (rows, cols int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rows == 0 || t.cols == 0 {
+		return 0, 0, errNoWindowSize
+	}
+
+	return t.rows, t.cols, nil
+}
+
+// Read reads the input without telnet commands.
+func (t *telnetConn) Read(p []byte) (int, error) {
+	t.conn.SetReadDeadline(time.Now().Add(telnetIdleTimeout))
+
+	n := 0
+	for n < len(p) {
+		// Return what was read instead of waiting for more
+		if n > 0 && t.r.Buffered() == 0 {
+			break
+		}
+
+		b, err := t.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				break
+			}
+
+			return 0, err
+		}
+
+		lastCR := t.lastCR
+		t.lastCR = b == '\r'
+
+		switch {
+		case b == telnetIAC:
+			literal, ok, err := t.command()
+			if err != nil {
+				return n, err
+			}
+
+			if ok {
+				p[n] = literal
+				n++
+			}
+		case b == 0 && lastCR:
+		default:
+			p[n] = b
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// command reads a telnet command after IAC. An escaped IAC is returned as
+// a literal byte.
+func (t *telnetConn) command() (literal byte, ok bool, err error) {
+	cmd, err := t.r.ReadByte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch cmd {
+	case telnetIAC:
+		return telnetIAC, true, nil
+	case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+		option, err := t.r.ReadByte()
+		if err != nil {
+			return 0, false, err
+		}
+
+		t.option(cmd, option)
+	case telnetSB:
+		data, err := t.subnegotiation()
+		if err != nil {
+			return 0, false, err
+		}
+
+		t.parameters(data)
+	}
+
+	// Other commands, like NOP and GA, have no meaning here
+	return 0, false, nil
+}
+
+// option answers the client enabling or disabling an option.
+func (t *telnetConn) option(cmd, option byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.telnet = true
+
+	switch {
+	case cmd == telnetWILL && option == telnetTerminalType:
+		t.queue([]byte{telnetIAC, telnetSB, telnetTerminalType, terminalTypeSend, telnetIAC, telnetSE})
+	case cmd == telnetWONT && option == telnetTerminalType:
+		t.known = true
+	case cmd == telnetWILL && option == telnetWindowSize:
+	case cmd == telnetWILL:
+		t.queue([]byte{telnetIAC, telnetDONT, option})
+	case cmd == telnetDO:
+		t.queue([]byte{telnetIAC, telnetWONT, option})
+	}
+}
+
+// subnegotiation reads the parameters of an option up to IAC SE.
+func (t *telnetConn) subnegotiation() ([]byte, error) {
+	var data []byte
+
+	for {
+		b, err := t.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if b == telnetIAC {
+			b, err = t.r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+
+			if b == telnetSE {
+				return data, nil
+			}
+		}
+
+		// Nobody sends parameters this long
+		if len(data) > 256 {
+			return nil, errors.New("telnet subnegotiation too long")
+		}
+
+		data = append(data, b)
+	}
+}
+
+// parameters records the terminal type or window size sent by the client.
+func (t *telnetConn) parameters(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch data[0] {
+	case telnetTerminalType:
+		if len(data) > 1 && data[1] == terminalTypeIs {
+			t.terminal = strings.ToLower(string(data[2:]))
+			t.known = true
+		}
+	case telnetWindowSize:
+		if len(data) == 5 {
+			t.cols = int(data[1])<<8 | int(data[2])
+			t.rows = int(data[3])<<8 | int(data[4])
+		}
+	}
+}
+
+// Write queues p with "\r\n" line endings, as telnet expects. It doesn't
+// wait for the client.
+func (t *telnetConn) Write(p []byte) (int, error) {
+	if err := t.queue(bytes.ReplaceAll(p, []byte("\n"), []byte("\r\n"))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// queue adds the data to the output. A client with more than
+// telnetMaxPending bytes waiting is dropped.
+func (t *telnetConn) queue(data []byte) error {
+	t.outMu.Lock()
+	defer t.outMu.Unlock()
+
+	switch {
+	case t.outErr != nil:
+		return t.outErr
+	case t.closing:
+		return net.ErrClosed
+	case len(t.out)+len(data) > telnetMaxPending:
+		t.drop(errSlowClient)
+		return errSlowClient
+	}
+
+	t.out = append(t.out, data...)
+	t.outCond.Signal()
+
+	return nil
+}
+
+// drop closes the connection, which ends the reads of the player too. The
+// output mutex must be held.
+func (t *telnetConn) drop(err error) {
+	if t.outErr == nil {
+		t.outErr = err
+	}
+
+	t.out = nil
+	t.outCond.Signal()
+	t.conn.Close()
+}
+
+// writeOutput sends the output to the client until the connection is
+// closed or dropped.
+func (t *telnetConn) writeOutput() {
+	defer close(t.written)
+
+	for {
+		t.outMu.Lock()
+		for len(t.out) == 0 && !t.closing && t.outErr == nil {
+			t.outCond.Wait()
+		}
+
+		data := t.out
+		t.out = nil
+		stop := t.outErr != nil || len(data) == 0
+		t.outMu.Unlock()
+
+		if stop {
+			return
+		}
+
+		t.conn.SetWriteDeadline(time.Now().Add(telnetWriteTimeout))
+		if _, err := t.conn.Write(data); err != nil {
+			t.outMu.Lock()
+			t.drop(err)
+			t.outMu.Unlock()
+			return
+		}
+	}
+}
+
+// Close sends the output that is left and closes the connection.
+func (t *telnetConn) Close() error {
+	t.outMu.Lock()
+	t.closing = true
+	t.outCond.Signal()
+	t.outMu.Unlock()
+
+	<-t.written
+
+	return t.conn.Close()
+}
+
+// ansiTerminal reports whether the terminal type understands ANSI escape codes.
+func ansiTerminal(terminal string) bool {
+	switch terminal {
+	case "", "dumb", "unknown", "network":
+		return false
+	}
+
+	return true
+}
+
+// terminalColorDepth guesses the colors of the terminal type.
+func terminalColorDepth(terminal string) minesweeper.ColorDepth {
+	switch {
+	case strings.Contains(terminal, "truecolor"), strings.Contains(terminal, "direct"):
+		return minesweeper.ColorTrue
+	case strings.Contains(terminal, "256"):
+		return minesweeper.Color256
+	}
+
+	return minesweeper.Color16
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// pipeClient is the client end of a connection to the lobby. The output of
+// the server is read into out as it is written.
+type pipeClient struct {
+	conn net.Conn
+
+	mu  sync.Mutex
+	out bytes.Buffer
+}
+
+func newPipeClient(t *testing.T) (*pipeClient, net.Conn) {
+	t.Helper()
+
+	server, conn := net.Pipe()
+	c := &pipeClient{conn: conn}
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := conn.Read(buf)
+			c.mu.Lock()
+			c.out.Write(buf[:n])
+			c.mu.Unlock()
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return c, server
+}
+
+func (c *pipeClient) send(t *testing.T, data string) {
+	t.Helper()
+
+	if _, err := c.conn.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *pipeClient) output() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.out.String()
+}
+
+// waitFor waits until the output contains text and returns the output.
+func (c *pipeClient) waitFor(t *testing.T, text string) string {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if out := c.output(); strings.Contains(out, text) {
+			return out
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("Expected %q in the output, but got %q", text, c.output())
+	return ""
+}
+
+func TestTelnetNegotiate(t *testing.T) {
+	c, server := newPipeClient(t)
+	tc := newTelnetConn(server)
+	defer tc.Close()
+
+	done := make(chan struct{})
+	go func() {
+		tc.negotiate(time.Second)
+		close(done)
+	}()
+
+	c.waitFor(t, string([]byte{telnetIAC, telnetDO, telnetTerminalType, telnetIAC, telnetDO, telnetWindowSize}))
+
+	// The client accepts both options and is asked for its terminal type
+	c.send(t, string([]byte{telnetIAC, telnetWILL, telnetWindowSize, telnetIAC, telnetWILL, telnetTerminalType}))
+	c.waitFor(t, string([]byte{telnetIAC, telnetSB, telnetTerminalType, terminalTypeSend, telnetIAC, telnetSE}))
+
+	c.send(t, string([]byte{telnetIAC, telnetSB, telnetWindowSize, 0, 80, 0, 24, telnetIAC, telnetSE}))
+	c.send(t, string([]byte{telnetIAC, telnetSB, telnetTerminalType, terminalTypeIs})+"XTERM-256COLOR"+string([]byte{telnetIAC, telnetSE}))
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the negotiation to end with the terminal type")
+	}
+
+	if terminal, ok := tc.terminalType(); !ok || terminal != "xterm-256color" {
+		t.Errorf("Expected the terminal xterm-256color, but got %q (%t)", terminal, ok)
+	}
+
+	if rows, cols, err := tc.size(); err != nil || rows != 24 || cols != 80 {
+		t.Errorf("Expected a 24 X 80 window, but got %d X %d (%v)", rows, cols, err)
+	}
+
+	// Other options are refused
+	go c.conn.Write([]byte{telnetIAC, telnetDO, 1, 'x', '\n'})
+
+	buf := make([]byte, 16)
+	if n, err := tc.Read(buf); err != nil || string(buf[:n]) != "x\n" {
+		t.Errorf("Expected the input x, but got %q (%v)", buf[:n], err)
+	}
+
+	c.waitFor(t, string([]byte{telnetIAC, telnetWONT, 1}))
+}
+
+func TestTelnetWithoutTelnet(t *testing.T) {
+	c, server := newPipeClient(t)
+	tc := newTelnetConn(server)
+	defer tc.Close()
+
+	// nc doesn't answer, the input is kept
+	go c.conn.Write([]byte("n\n"))
+
+	tc.negotiate(time.Second)
+
+	if _, ok := tc.terminalType(); ok {
+		t.Errorf("Expected no terminal type from a client without telnet")
+	}
+
+	if _, _, err := tc.size(); !errors.Is(err, errNoWindowSize) {
+		t.Errorf("Expected no window size, but got %v", err)
+	}
+
+	buf := make([]byte, 16)
+	if n, err := tc.Read(buf); err != nil || string(buf[:n]) != "n\n" {
+		t.Errorf("Expected the input n, but got %q (%v)", buf[:n], err)
+	}
+}
+
+func TestTelnetRead(t *testing.T) {
+	c, server := newPipeClient(t)
+	tc := newTelnetConn(server)
+	defer tc.Close()
+
+	// Commands are removed, an escaped IAC is kept and "\r\0" is a carriage
+	// return
+	go c.conn.Write([]byte("a" + string([]byte{telnetIAC, 241, telnetIAC, telnetIAC}) + "b\r\x00c\n"))
+
+	var got []byte
+	buf := make([]byte, 16)
+	for !bytes.HasSuffix(got, []byte("\n")) {
+		n, err := tc.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, buf[:n]...)
+	}
+
+	if want := "a\xffb\rc\n"; string(got) != want {
+		t.Errorf("Expected %q, but got %q", want, got)
+	}
+}
+
+func TestTelnetWrite(t *testing.T) {
+	c, server := newPipeClient(t)
+	tc := newTelnetConn(server)
+
+	tc.Write([]byte("one\ntwo\n"))
+	c.waitFor(t, "one\r\ntwo\r\n")
+
+	// The output left is sent before the connection is closed
+	tc.Write([]byte("bye\n"))
+	tc.Close()
+
+	c.waitFor(t, "bye\r\n")
+
+	if _, err := tc.Write([]byte("late\n")); err == nil {
+		t.Errorf("Expected an error after close")
+	}
+}
+
+func TestTelnetSlowClient(t *testing.T) {
+	// Nobody reads the output of the server
+	server, client := net.Pipe()
+	defer client.Close()
+
+	tc := newTelnetConn(server)
+	defer tc.Close()
+
+	chunk := bytes.Repeat([]byte("x"), 64*1024)
+
+	start := time.Now()
+
+	var err error
+	for i := 0; i < 2*telnetMaxPending/len(chunk) && err == nil; i++ {
+		_, err = tc.Write(chunk)
+	}
+
+	if !errors.Is(err, errSlowClient) {
+		t.Errorf("Expected the client to be dropped, but got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the writes not to wait for the client, but they took %s", elapsed)
+	}
+
+	// The reads of the player end with the connection
+	if _, err := tc.Read(make([]byte, 1)); err == nil {
+		t.Errorf("Expected the read to fail, but got %v", err)
+	}
+}
+
+func TestTerminalDetection(t *testing.T) {
+	tests := []struct {
+		terminal string
+		ansi     bool
+		depth    minesweeper.ColorDepth
+	}{
+		{"", false, minesweeper.Color16},
+		{"dumb", false, minesweeper.Color16},
+		{"network", false, minesweeper.Color16},
+		{"xterm", true, minesweeper.Color16},
+		{"xterm-256color", true, minesweeper.Color256},
+		{"xterm-direct", true, minesweeper.ColorTrue},
+		{"vt100-truecolor", true, minesweeper.ColorTrue},
+	}
+
+	for _, test := range tests {
+		if ansi := ansiTerminal(test.terminal); ansi != test.ansi {
+			t.Errorf("Expected ANSI %t for %q, but got %t", test.ansi, test.terminal, ansi)
+		}
+
+		if depth := terminalColorDepth(test.terminal); depth != test.depth {
+			t.Errorf("Expected the color depth %v for %q, but got %v", test.depth, test.terminal, depth)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoRawMode = errors.New("full-screen mode is not supported on this platform")
+
+type terminalState struct{}
+
+func enableRawMode(fd int) (*terminalState, error) {
+	return nil, errNoRawMode
+}
+
+func restoreTerminal(fd int, state *terminalState) error {
+	return errNoRawMode
+}
+
+func terminalSize(fd int) (rows, cols int, err error) {
+	return 0, 0, errNoRawMode
+}
+
+func notifyResize(ch chan<- os.Signal) {}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/TechMDW/minesweeper/internal/util"
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// Escape codes used by the full-screen mode
+const (
+	tAltScreenOn  = "\033[?1049h"
+	tAltScreenOff = "\033[?1049l"
+	tCursorHide   = "\033[?25l"
+	tCursorShow   = "\033[?25h"
+)
+
+// tui is the state of the full-screen mode.
+type tui struct {
+	config *Config
+	board  *minesweeper.Board
+	cursor minesweeper.Position
+
+	// team and the player whose turn it is, with -players
+	team   *minesweeper.Team
+	player *minesweeper.Player
+
+	startTime time.Time
+	endTime   time.Time
+
+	// Line on the screen the board starts at, used to map mouse clicks
+	boardTop int
+
+	// Mouse buttons held down, pressing left and right together chords
+	leftDown  bool
+	rightDown bool
+	chording  bool
+	chorded   bool
+}
+
+// playTUI plays games in full-screen mode until the player quits.
+//
+// The terminal is restored when the game ends, also when it panics.
+func playTUI(config *Config) error {
+	fd := int(os.Stdin.Fd())
+
+	state, err := enableRawMode(fd)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(tAltScreenOn + tCursorHide + tMouseOn)
+
+	t := &tui{config: config}
+
+	defer func() {
+		fmt.Print(tMouseOff + tCursorShow + tAltScreenOff)
+		restoreTerminal(fd, state)
+
+		if t.board != nil && t.board.Status() != minesweeper.StatusPlaying {
+			printStatistics(t.board, t.startTime, config, true)
+		}
+	}()
+
+	input := make(chan []byte)
+	go readInput(os.Stdin, input)
+
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	defer signal.Stop(resize)
+
+	// Update the timer every second
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	t.newGame()
+	t.draw()
+
+	// pending is an escape sequence cut by a read, escape fires when the
+	// rest didn't come in time
+	var pending []byte
+	var escape <-chan time.Time
+
+	handle := func(events []inputEvent) (quit bool) {
+		for _, event := range events {
+			if t.handle(event) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for {
+		select {
+		case data, ok := <-input:
+			if !ok {
+				return nil
+			}
+
+			var events []inputEvent
+			events, pending = parseKeys(append(pending, data...))
+
+			escape = nil
+			if len(pending) > 0 {
+				escape = time.After(escapeTimeout)
+			}
+
+			if handle(events) {
+				return nil
+			}
+		case <-escape:
+			escape = nil
+			events := flushKeys(pending)
+			pending = nil
+
+			if handle(events) {
+				return nil
+			}
+		case <-ticker.C:
+		case <-resize:
+		}
+
+		t.draw()
+	}
+}
+
+// newGame starts a new game with the seed of the config.
+func (t *tui) newGame() {
+	t.board = newGameBoard(t.config)
+	t.cursor = minesweeper.Position{Row: t.board.Rows / 2, Col: t.board.Cols / 2}
+	t.startTime = time.Time{}
+	t.endTime = time.Time{}
+
+	t.board.Subscribe(func(event minesweeper.Event) {
+		switch event.(type) {
+		case minesweeper.GameStartedEvent:
+			t.startTime = time.Now()
+		case minesweeper.MineHitEvent, minesweeper.GameWonEvent:
+			t.endTime = time.Now()
+		}
+	})
+
+	t.team, t.player = nil, nil
+	if len(t.config.players) > 0 {
+		t.team = minesweeper.NewTeam(t.board)
+		for _, name := range t.config.players {
+			t.team.Join(name)
+		}
+
+		t.player = t.team.Players()[0]
+		t.cursor = t.player.Cursor
+	}
+
+	t.config.team = t.team
+}
+
+// play plays a move of the player whose turn it is.
+func (t *tui) play(move func()) {
+	t.config.clicks++
+
+	if t.team == nil {
+		move()
+
+		if t.board.Status() != minesweeper.StatusPlaying {
+			recordGame(t.board, t.elapsed(), t.config)
+		}
+
+		return
+	}
+
+	t.team.Play(t.player, func() error {
+		move()
+		return nil
+	})
+
+	// The cursor stays where the player put it
+	t.player.Cursor = t.cursor
+}
+
+// nextPlayer passes the turn to the next player of the team.
+func (t *tui) nextPlayer() {
+	if t.team == nil {
+		return
+	}
+
+	players := t.team.Players()
+	for i, p := range players {
+		if p == t.player {
+			t.player.Cursor = t.cursor
+			t.player = players[(i+1)%len(players)]
+			t.cursor = t.player.Cursor
+			return
+		}
+	}
+}
+
+// handle handles a key press or mouse click. It returns true if the player quits.
+func (t *tui) handle(event inputEvent) (quit bool) {
+	if event.mouse {
+		t.handleMouse(event)
+		return false
+	}
+
+	playing := t.board.Status() == minesweeper.StatusPlaying
+	row, col := t.cursor.Row, t.cursor.Col
+
+	switch event.key {
+	case keyUp, "k":
+		t.moveCursor(-1, 0)
+	case keyDown, "j":
+		t.moveCursor(1, 0)
+	case keyLeft, "h":
+		t.moveCursor(0, -1)
+	case keyRight, "l":
+		t.moveCursor(0, 1)
+	case "K":
+		t.moveCursor(-t.board.DisplayOptions.Viewport.Rows, 0)
+	case "J":
+		t.moveCursor(t.board.DisplayOptions.Viewport.Rows, 0)
+	case "H":
+		t.moveCursor(0, -t.board.DisplayOptions.Viewport.Cols)
+	case "L":
+		t.moveCursor(0, t.board.DisplayOptions.Viewport.Cols)
+	case keySpace, keyEnter:
+		if playing && !t.board.IsFlagged(row, col) {
+			t.play(func() { t.board.Reveal(row, col) })
+		}
+	case "f":
+		if playing && !t.board.Cells[row][col].IsRevealed {
+			t.play(func() { t.board.ToggleFlag(row, col) })
+		}
+	case "d":
+		if playing {
+			t.play(func() { t.board.Chord(row, col) })
+		}
+	case keyTab:
+		t.nextPlayer()
+	case "r":
+		if !playing {
+			t.newGame()
+		}
+	case "n":
+		if !playing {
+			t.config.seed = time.Now().UnixNano()
+			t.newGame()
+		}
+	case "q", keyEsc, keyCtrlC:
+		return true
+	}
+
+	return false
+}
+
+// handleMouse handles a mouse click. Left click reveals, right click flags
+// and middle click or left and right together chord. The cursor follows the
+// mouse.
+func (t *tui) handleMouse(event inputEvent) {
+	pos, onBoard := t.board.CellAt(event.x, event.y-t.boardTop)
+	if onBoard {
+		t.cursor = pos
+	}
+
+	act := onBoard && t.board.Status() == minesweeper.StatusPlaying
+
+	if event.press {
+		switch event.button {
+		case mouseLeft:
+			t.leftDown = true
+			t.chording = t.chording || t.rightDown
+		case mouseRight:
+			t.rightDown = true
+			t.chording = t.chording || t.leftDown
+
+			if act && !t.chording && !t.board.Cells[pos.Row][pos.Col].IsRevealed {
+				t.play(func() { t.board.ToggleFlag(pos.Row, pos.Col) })
+			}
+		case mouseMiddle:
+			if act {
+				t.play(func() { t.board.Chord(pos.Row, pos.Col) })
+			}
+		}
+
+		return
+	}
+
+	switch event.button {
+	case mouseLeft:
+		t.leftDown = false
+	case mouseRight:
+		t.rightDown = false
+	}
+
+	// Chord once on the first release, wait for both buttons to be released
+	if t.chording {
+		if act && !t.chorded {
+			t.play(func() { t.board.Chord(pos.Row, pos.Col) })
+		}
+
+		t.chorded = true
+
+		if !t.leftDown && !t.rightDown {
+			t.chording, t.chorded = false, false
+		}
+
+		return
+	}
+
+	if act && event.button == mouseLeft && !t.board.IsFlagged(pos.Row, pos.Col) {
+		t.play(func() { t.board.Reveal(pos.Row, pos.Col) })
+	}
+}
+
+// moveCursor moves the cursor, it stops at the edges of the board.
+func (t *tui) moveCursor(rows, cols int) {
+	t.cursor.Row = clamp(t.cursor.Row+rows, 0, t.board.Rows-1)
+	t.cursor.Col = clamp(t.cursor.Col+cols, 0, t.board.Cols-1)
+}
+
+// clamp limits value to the range min to max.
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+
+	if value > max {
+		return max
+	}
+
+	return value
+}
+
+// elapsed returns the time played in the current game.
+func (t *tui) elapsed() time.Duration {
+	switch {
+	case t.startTime.IsZero():
+		return 0
+	case !t.endTime.IsZero():
+		return t.endTime.Sub(t.startTime)
+	}
+
+	return time.Since(t.startTime)
+}
+
+// draw redraws the whole screen.
+func (t *tui) draw() {
+	var buf bytes.Buffer
+
+	// Header and footer, the viewport follows the cursor
+	reservedLines := 4
+	if t.team != nil {
+		reservedLines++
+	}
+
+	fitViewport(t.board, t.config, reservedLines)
+	t.board.DisplayOptions.Viewport.Follow(t.board, t.cursor.Row, t.cursor.Col)
+
+	buf.WriteString(dClear)
+
+	seconds := int(t.elapsed().Seconds())
+	fmt.Fprintf(&buf, "Mines: %d   Time: %02d:%02d   Cell: %s\n", t.board.NumMines-t.board.FlagsCount(), seconds/60, seconds%60, t.board.FormatCell(t.cursor.Row, t.cursor.Col))
+	fmt.Fprintln(&buf, " ", util.FormatPercentageBar(t.board.RevealedPercentage(), barWidth(t.board)))
+	t.boardTop = 2
+
+	// Every player has a cursor in their color
+	renderer := minesweeper.ANSIRenderer{Cursor: &t.cursor}
+	if t.team != nil {
+		t.player.Cursor = t.cursor
+		renderer = minesweeper.ANSIRenderer{Cursors: t.team.Cursors()}
+
+		turn := minesweeper.Style{Foreground: t.player.Color, Bold: true}.Paint(t.player.Name, t.config.colorDepth)
+		fmt.Fprintf(&buf, "Turn: %s (Tab = next player)   ", turn)
+		printPlayers(t.board, &Config{out: &buf, team: t.team, colorDepth: t.config.colorDepth})
+		t.boardTop++
+	}
+
+	status := t.board.Status()
+	renderer.Render(&buf, t.board.PlayerView(), t.board.DisplayOptions)
+
+	buf.WriteString("\n")
+
+	switch status {
+	case minesweeper.StatusWon:
+		buf.WriteString("\x1b[32mYou won!\x1b[0m  ")
+		if t.config.rank > 0 {
+			fmt.Fprintf(&buf, "#%d on the %s leaderboard  ", t.config.rank, t.config.difficulty)
+		}
+
+		buf.WriteString("r = retry same seed, n = new seed, q = quit\n")
+	case minesweeper.StatusLost:
+		buf.WriteString("\x1b[31mYou lost!\x1b[0m  r = retry same seed, n = new seed, q = quit\n")
+	default:
+		buf.WriteString("arrows/hjkl = move, space/left click = reveal, f/right click = flag, d/middle click = chord, q = quit\n")
+	}
+
+	os.Stdout.Write(buf.Bytes())
+}
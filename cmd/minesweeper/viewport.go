@@ -0,0 +1,133 @@
+package main
+
+import (
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// Width of the minimap and the space in front of it
+const minimapWidth = 19
+
+// fitViewport sizes the viewport of the board to -viewRows and -viewCols, or
+// to the terminal if they are 0. reservedLines is the number of lines used
+// around the board.
+func fitViewport(board *minesweeper.Board, config *Config, reservedLines int) {
+	viewport := board.DisplayOptions.Viewport
+	if viewport == nil {
+		return
+	}
+
+	rows, cols := config.viewRows, config.viewCols
+
+	if termRows, termCols, err := config.size(); err == nil && termRows > 0 {
+		options := board.DisplayOptions
+
+		if rows == 0 {
+			// The top index line is there without the index too
+			rows = termRows - reservedLines - 1
+
+			if *options.BottomIndex {
+				rows--
+			}
+		}
+
+		if cols == 0 {
+			// Measure the whole board, its indices are the widest
+			saved := *viewport
+			*viewport = minesweeper.Viewport{Rows: board.Rows, Cols: board.Cols}
+			layout := board.Layout()
+			*viewport = saved
+
+			width := termCols - layout.Gutter
+			if *options.RightIndex {
+				width -= 2 + layout.RowIndexWidth
+			}
+
+			step := layout.Pitch()
+			if *options.Minimap && board.Cols*step > width {
+				width -= minimapWidth
+			}
+
+			cols = (width + layout.SeparatorWidth) / step
+		}
+	}
+
+	// Without a terminal size 0 shows the whole board
+	if rows == 0 && cols == 0 {
+		viewport.Resize(board, board.Rows, board.Cols)
+		return
+	}
+
+	viewport.Resize(board, atLeastOne(rows), atLeastOne(cols))
+}
+
+// barWidth returns the width of the progress bar. The bar is printed after
+// two spaces in brackets and ends with the last visible column of the board.
+func barWidth(board *minesweeper.Board) int {
+	cols := board.Cols
+	if viewport := board.DisplayOptions.Viewport; viewport != nil && viewport.Cols > 0 {
+		cols = viewport.Cols
+	}
+
+	layout := board.Layout()
+
+	return atLeastOne(layout.Gutter + cols*layout.Pitch() - layout.SeparatorWidth - 4)
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+
+	return n
+}
+
+// handlePan moves the viewport by n rows or columns (default 1) in the direction.
+func handlePan(command *Command, board *minesweeper.Board) error {
+	n := 1
+	if args := command.args(); len(args) > 0 {
+		num, err := parseInt(args[0], "number of "+panUnit(command.Action))
+		if err != nil {
+			return err
+		}
+
+		n = num
+	}
+
+	viewport := board.DisplayOptions.Viewport
+
+	switch command.Action {
+	case "up":
+		viewport.Pan(board, -n, 0)
+	case "down":
+		viewport.Pan(board, n, 0)
+	case "left":
+		viewport.Pan(board, 0, -n)
+	case "right":
+		viewport.Pan(board, 0, n)
+	}
+
+	return nil
+}
+
+func panUnit(direction string) string {
+	if direction == "up" || direction == "down" {
+		return "rows"
+	}
+
+	return "columns"
+}
+
+// handleGoto centers the viewport on the cell at position (row, col), or
+// the named cell with chess coordinates.
+func handleGoto(command *Command, board *minesweeper.Board) error {
+	cells, err := parseCells(command, false, board)
+	if err != nil {
+		return err
+	}
+
+	// Center on the middle of a range
+	first, last := cells[0], cells[len(cells)-1]
+	board.DisplayOptions.Viewport.CenterOn(board, (first.Row+last.Row)/2, (first.Col+last.Col)/2)
+
+	return nil
+}
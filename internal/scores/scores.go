@@ -0,0 +1,322 @@
+// Package scores keeps the results of games in data files: the local
+// leaderboard of won games and the history of all finished games for the
+// lifetime statistics.
+package scores
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// FileName is the name of the leaderboard in the data directory.
+const FileName = "scores.json"
+
+// DefaultTop is the number of results shown for a configuration.
+const DefaultTop = 10
+
+// MaxEntries is the number of results kept per configuration, the fastest
+// ones.
+const MaxEntries = 100
+
+// Time a game waits for another one to write a data file, and the age of a
+// lock left behind by a game that was killed while writing
+const (
+	lockTimeout = 5 * time.Second
+	staleLock   = 30 * time.Second
+)
+
+var errLocked = errors.New("data file is locked by another game")
+
+// Settings is the board configuration of a game. Results are only ranked
+// against results with the same settings.
+type Settings struct {
+	Difficulty string `json:"difficulty"`
+	Rows       int    `json:"rows"`
+	Cols       int    `json:"cols"`
+	Mines      int    `json:"mines"`
+}
+
+// SettingsOf returns the settings of a board with the size and mines.
+func SettingsOf(rows, cols, mines int) Settings {
+	return Settings{
+		Difficulty: minesweeper.DifficultyOf(rows, cols, mines),
+		Rows:       rows,
+		Cols:       cols,
+		Mines:      mines,
+	}
+}
+
+func (s Settings) String() string {
+	return minesweeper.Difficulty{Name: s.Difficulty, Rows: s.Rows, Cols: s.Cols, Mines: s.Mines}.String()
+}
+
+// Entry is the result of a won game. Efficiency is the 3BV of the board per
+// click, 1 is a game without a wasted click.
+type Entry struct {
+	Name         string        `json:"name"`
+	Time         time.Duration `json:"time"`
+	BV3PerSecond float64       `json:"bv3PerSecond"`
+	Efficiency   float64       `json:"efficiency"`
+	Seed         int64         `json:"seed"`
+	Settings     Settings      `json:"settings"`
+	Date         time.Time     `json:"date"`
+}
+
+// Leaderboard is the list of results of all configurations.
+type Leaderboard struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Dir returns the directory of the data files: minesweeper in
+// $XDG_DATA_HOME, or in ~/.local/share if it is not set.
+func Dir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "minesweeper"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("no data directory: %w", err)
+	}
+
+	return filepath.Join(home, ".local", "share", "minesweeper"), nil
+}
+
+// Path returns the path of the leaderboard in the data directory.
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load reads the leaderboard from the file. A file that doesn't exist yet
+// is an empty leaderboard.
+func Load(path string) (*Leaderboard, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Leaderboard{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var l Leaderboard
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("invalid leaderboard %s: %w", path, err)
+	}
+
+	return &l, nil
+}
+
+// Save writes the leaderboard to the file, creating its directory. The file
+// is replaced at once, a failed write leaves the old file.
+func (l *Leaderboard) Save(path string) error {
+	return writeJSON(path, l)
+}
+
+// Add adds the result and returns its rank among the results with the same
+// settings, starting at 1. Only the MaxEntries fastest results of the
+// settings are kept, a slower result is ranked but not added.
+func (l *Leaderboard) Add(entry Entry) int {
+	l.Entries = append(l.Entries, entry)
+
+	top := l.Top(entry.Settings, 0)
+
+	rank := 0
+	for i, e := range top {
+		if e == entry {
+			rank = i + 1
+			break
+		}
+	}
+
+	if len(top) > MaxEntries {
+		kept := make(map[Entry]bool, MaxEntries)
+		for _, e := range top[:MaxEntries] {
+			kept[e] = true
+		}
+
+		entries := l.Entries[:0]
+		for _, e := range l.Entries {
+			if e.Settings != entry.Settings || kept[e] {
+				entries = append(entries, e)
+			}
+		}
+
+		l.Entries = entries
+	}
+
+	return rank
+}
+
+// Top returns the n fastest results with the settings, all of them if n is
+// 0. Results with the same time are ordered by date.
+func (l *Leaderboard) Top(settings Settings, n int) []Entry {
+	var top []Entry
+	for _, e := range l.Entries {
+		if e.Settings == settings {
+			top = append(top, e)
+		}
+	}
+
+	sort.SliceStable(top, func(i, j int) bool {
+		if top[i].Time != top[j].Time {
+			return top[i].Time < top[j].Time
+		}
+
+		return top[i].Date.Before(top[j].Date)
+	})
+
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+
+	return top
+}
+
+// Settings returns the settings that have results: the presets from easiest
+// to hardest, then the custom boards by size.
+func (l *Leaderboard) Settings() []Settings {
+	seen := make(map[Settings]bool)
+
+	var all []Settings
+	for _, e := range l.Entries {
+		if !seen[e.Settings] {
+			seen[e.Settings] = true
+			all = append(all, e.Settings)
+		}
+	}
+
+	sortSettings(all)
+
+	return all
+}
+
+// Record adds the result to the leaderboard in the file and returns the
+// leaderboard and the rank of the result. The file is locked, so games that
+// end at the same time don't lose each other's results.
+func Record(path string, entry Entry) (*Leaderboard, int, error) {
+	unlock, err := lock(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer unlock()
+
+	l, err := Load(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rank := l.Add(entry)
+
+	if err := l.Save(path); err != nil {
+		return nil, 0, err
+	}
+
+	return l, rank, nil
+}
+
+// sortSettings sorts the presets from easiest to hardest before the custom
+// boards by size.
+func sortSettings(all []Settings) {
+	preset := func(s Settings) int {
+		for i, d := range minesweeper.Difficulties {
+			if d.Name == s.Difficulty {
+				return i
+			}
+		}
+
+		return len(minesweeper.Difficulties)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+
+		switch {
+		case preset(a) != preset(b):
+			return preset(a) < preset(b)
+		case a.Rows*a.Cols != b.Rows*b.Cols:
+			return a.Rows*a.Cols < b.Rows*b.Cols
+		case a.Rows != b.Rows:
+			return a.Rows < b.Rows
+		}
+
+		return a.Mines < b.Mines
+	})
+}
+
+// lock takes the lock of the data file, a file next to it that only one
+// game can create. It waits up to lockTimeout for another game, a lock older
+// than staleLock is removed. The returned function releases the lock.
+func lock(path string) (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	name := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(name) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(name); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(name)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s", errLocked, name)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// writeJSON writes v to the file through a temporary file that replaces it.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
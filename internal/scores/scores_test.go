@@ -0,0 +1,165 @@
+package scores
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", FileName)
+
+	beginner := SettingsOf(minesweeper.Beginner.Rows, minesweeper.Beginner.Cols, minesweeper.Beginner.Mines)
+	custom := SettingsOf(5, 5, 3)
+
+	if beginner.Difficulty != "beginner" || custom.Difficulty != minesweeper.DifficultyCustom {
+		t.Fatalf("Expected beginner and custom settings, but got %v and %v", beginner, custom)
+	}
+
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Name: "alice", Time: 20 * time.Second, Settings: beginner, Date: date},
+		{Name: "bob", Time: 10 * time.Second, Settings: beginner, Date: date.Add(time.Hour)},
+		{Name: "carol", Time: 5 * time.Second, Settings: custom, Date: date.Add(2 * time.Hour)},
+		{Name: "dave", Time: 10 * time.Second, Settings: beginner, Date: date.Add(3 * time.Hour)},
+	}
+
+	ranks := []int{1, 1, 1, 2}
+	for i, entry := range entries {
+		_, rank, err := Record(path, entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if rank != ranks[i] {
+			t.Errorf("Expected %s to rank %d, but got %d", entry.Name, ranks[i], rank)
+		}
+	}
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ties are ordered by date, other settings are left out
+	top := l.Top(beginner, 2)
+	if len(top) != 2 || top[0].Name != "bob" || top[1].Name != "dave" {
+		t.Errorf("Expected bob and dave on top, but got %+v", top)
+	}
+
+	if settings := l.Settings(); len(settings) != 2 || settings[0] != beginner || settings[1] != custom {
+		t.Errorf("Expected the beginner and custom settings, but got %v", settings)
+	}
+}
+
+func TestRecordConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	settings := SettingsOf(5, 5, 3)
+
+	// Games ending at the same time keep all their results
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			if _, _, err := Record(path, Entry{Name: fmt.Sprint(i), Time: time.Duration(i+1) * time.Second, Settings: settings}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(l.Entries) != 20 {
+		t.Errorf("Expected 20 results, but got %d", len(l.Entries))
+	}
+
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock to be removed, but got %v", err)
+	}
+}
+
+func TestRecordStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	if err := os.WriteFile(path+".lock", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-2 * staleLock)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := Record(path, Entry{Name: "alice", Time: time.Second}); err != nil {
+		t.Errorf("Expected the stale lock to be removed, but got %v", err)
+	}
+}
+
+func TestAddKeepsFastest(t *testing.T) {
+	settings := SettingsOf(5, 5, 3)
+	other := SettingsOf(6, 6, 3)
+
+	l := &Leaderboard{Entries: []Entry{{Name: "other", Time: time.Hour, Settings: other}}}
+
+	for i := 0; i < MaxEntries; i++ {
+		l.Add(Entry{Name: fmt.Sprint(i), Time: time.Duration(i+1) * time.Second, Settings: settings})
+	}
+
+	// A result slower than the kept ones is ranked but not kept
+	if rank := l.Add(Entry{Name: "slow", Time: time.Hour, Settings: settings}); rank != MaxEntries+1 {
+		t.Errorf("Expected the rank %d, but got %d", MaxEntries+1, rank)
+	}
+
+	if rank := l.Add(Entry{Name: "fast", Time: time.Millisecond, Settings: settings}); rank != 1 {
+		t.Errorf("Expected the rank 1, but got %d", rank)
+	}
+
+	top := l.Top(settings, 0)
+	if len(top) != MaxEntries || top[0].Name != "fast" || top[len(top)-1].Name != fmt.Sprint(MaxEntries-2) {
+		t.Errorf("Expected the %d fastest results, but got %d from %s to %s", MaxEntries, len(top), top[0].Name, top[len(top)-1].Name)
+	}
+
+	if len(l.Top(other, 0)) != 1 {
+		t.Errorf("Expected the results of other settings to be kept")
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	l, err := Load(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(l.Entries) != 0 {
+		t.Errorf("Expected an empty leaderboard, but got %d entries", len(l.Entries))
+	}
+}
+
+func TestDir(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/data")
+
+	dir, err := Dir()
+	if err != nil || dir != filepath.Join("/data", "minesweeper") {
+		t.Errorf("Expected the minesweeper dir in XDG_DATA_HOME, but got %q (%v)", dir, err)
+	}
+
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("HOME", "/home/alice")
+
+	dir, err = Dir()
+	if err != nil || dir != filepath.Join("/home/alice", ".local", "share", "minesweeper") {
+		t.Errorf("Expected the minesweeper dir in ~/.local/share, but got %q (%v)", dir, err)
+	}
+}
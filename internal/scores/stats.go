@@ -0,0 +1,232 @@
+package scores
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// HistoryFileName is the name of the history of games in the data
+// directory, next to the leaderboard.
+const HistoryFileName = "games.json"
+
+// MaxGames is the number of games kept in the history, the oldest games
+// are removed first.
+const MaxGames = 10000
+
+// ClearedBuckets is the number of buckets of Stats.Cleared, each one holds
+// a tenth of the board.
+const ClearedBuckets = 10
+
+// Cell is the position of a cell on the board.
+type Cell struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// Game is a finished game in the history. Cleared is the share of safe
+// cells revealed, Hit the mine that lost the game.
+type Game struct {
+	Settings Settings      `json:"settings"`
+	Won      bool          `json:"won"`
+	Time     time.Duration `json:"time"`
+	Cleared  float64       `json:"cleared"`
+	Hit      *Cell         `json:"hit,omitempty"`
+	Clicks   int           `json:"clicks"`
+	Date     time.Time     `json:"date"`
+}
+
+// History is the list of finished games of all configurations, oldest
+// first.
+type History struct {
+	Games []Game `json:"games"`
+}
+
+// HistoryPath returns the path of the history in the data directory.
+func HistoryPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, HistoryFileName), nil
+}
+
+// LoadHistory reads the history from the file. A file that doesn't exist
+// yet is an empty history.
+func LoadHistory(path string) (*History, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &History{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var h History
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("invalid history %s: %w", path, err)
+	}
+
+	return &h, nil
+}
+
+// Save writes the history to the file, see Leaderboard.Save.
+func (h *History) Save(path string) error {
+	return writeJSON(path, h)
+}
+
+// AddGame adds the game to the history in the file and returns the history.
+// The file is locked like in Record and keeps the last MaxGames games.
+func AddGame(path string, game Game) (*History, error) {
+	unlock, err := lock(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	h, err := LoadHistory(path)
+	if err != nil {
+		return nil, err
+	}
+
+	h.Games = append(h.Games, game)
+	if len(h.Games) > MaxGames {
+		h.Games = h.Games[len(h.Games)-MaxGames:]
+	}
+
+	if err := h.Save(path); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// Stats are the lifetime statistics of a configuration.
+//
+// Times are of won games, TotalTime is the time of all games. Cleared
+// counts the losses by the share of the board cleared, Cleared[0] is below
+// a tenth. Hits counts the mines hit per cell.
+type Stats struct {
+	Settings      Settings        `json:"settings"`
+	Played        int             `json:"played"`
+	Won           int             `json:"won"`
+	CurrentStreak int             `json:"currentStreak"`
+	BestStreak    int             `json:"bestStreak"`
+	BestTime      time.Duration   `json:"bestTime"`
+	AverageTime   time.Duration   `json:"averageTime"`
+	TotalTime     time.Duration   `json:"totalTime"`
+	Times         []time.Duration `json:"times"`
+	Cleared       []int           `json:"cleared"`
+	Hits          [][]int         `json:"hits"`
+}
+
+// WinRate returns the share of games won, between 0 and 1.
+func (s *Stats) WinRate() float64 {
+	if s.Played == 0 {
+		return 0
+	}
+
+	return float64(s.Won) / float64(s.Played)
+}
+
+// Losses returns the number of games lost.
+func (s *Stats) Losses() int {
+	return s.Played - s.Won
+}
+
+// Stats returns the statistics of every configuration with games, sorted
+// like Leaderboard.Settings.
+func (h *History) Stats() []*Stats {
+	bySettings := make(map[Settings]*Stats)
+
+	var all []Settings
+	for _, game := range h.Games {
+		s := bySettings[game.Settings]
+		if s == nil {
+			s = newStats(game.Settings)
+			bySettings[game.Settings] = s
+			all = append(all, game.Settings)
+		}
+
+		s.add(game)
+	}
+
+	sortSettings(all)
+
+	stats := make([]*Stats, len(all))
+	for i, settings := range all {
+		stats[i] = bySettings[settings]
+	}
+
+	return stats
+}
+
+// StatsOf returns the statistics of the configuration, empty if it has no
+// games.
+func (h *History) StatsOf(settings Settings) *Stats {
+	s := newStats(settings)
+	for _, game := range h.Games {
+		if game.Settings == settings {
+			s.add(game)
+		}
+	}
+
+	return s
+}
+
+func newStats(settings Settings) *Stats {
+	s := &Stats{
+		Settings: settings,
+		Cleared:  make([]int, ClearedBuckets),
+		Hits:     make([][]int, settings.Rows),
+	}
+
+	for r := range s.Hits {
+		s.Hits[r] = make([]int, settings.Cols)
+	}
+
+	return s
+}
+
+// add counts the game, games are added oldest first.
+func (s *Stats) add(game Game) {
+	s.Played++
+	s.TotalTime += game.Time
+
+	if !game.Won {
+		s.CurrentStreak = 0
+
+		bucket := int(game.Cleared * ClearedBuckets)
+		if bucket >= ClearedBuckets {
+			bucket = ClearedBuckets - 1
+		}
+
+		if bucket >= 0 {
+			s.Cleared[bucket]++
+		}
+
+		if hit := game.Hit; hit != nil && hit.Row >= 0 && hit.Row < len(s.Hits) && hit.Col >= 0 && hit.Col < len(s.Hits[hit.Row]) {
+			s.Hits[hit.Row][hit.Col]++
+		}
+
+		return
+	}
+
+	s.Won++
+	s.CurrentStreak++
+	if s.CurrentStreak > s.BestStreak {
+		s.BestStreak = s.CurrentStreak
+	}
+
+	if s.BestTime == 0 || game.Time < s.BestTime {
+		s.BestTime = game.Time
+	}
+
+	s.Times = append(s.Times, game.Time)
+	s.AverageTime = (s.AverageTime*time.Duration(s.Won-1) + game.Time) / time.Duration(s.Won)
+}
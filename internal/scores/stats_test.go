@@ -0,0 +1,118 @@
+package scores
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HistoryFileName)
+
+	small := SettingsOf(3, 3, 1)
+	other := SettingsOf(5, 5, 3)
+
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	games := []Game{
+		{Settings: small, Won: true, Time: 10 * time.Second},
+		{Settings: small, Won: true, Time: 20 * time.Second},
+		{Settings: small, Time: 5 * time.Second, Cleared: 0.25, Hit: &Cell{Row: 1, Col: 2}},
+		{Settings: other, Time: time.Second, Cleared: 1},
+		{Settings: small, Won: true, Time: 30 * time.Second},
+	}
+
+	for i, game := range games {
+		game.Date = date.Add(time.Duration(i) * time.Hour)
+		if _, err := AddGame(path, game); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all := h.Stats()
+	if len(all) != 2 || all[0].Settings != small || all[1].Settings != other {
+		t.Fatalf("Expected stats of 2 configurations, but got %+v", all)
+	}
+
+	s := all[0]
+	if s.Played != 4 || s.Won != 3 || s.Losses() != 1 || s.WinRate() != 0.75 {
+		t.Errorf("Expected 3 of 4 games won, but got %d of %d", s.Won, s.Played)
+	}
+
+	if s.CurrentStreak != 1 || s.BestStreak != 2 {
+		t.Errorf("Expected a streak of 1 and a best streak of 2, but got %d and %d", s.CurrentStreak, s.BestStreak)
+	}
+
+	if s.BestTime != 10*time.Second || s.AverageTime != 20*time.Second || s.TotalTime != 65*time.Second {
+		t.Errorf("Expected a best time of 10s, an average of 20s and a total of 65s, but got %s, %s and %s", s.BestTime, s.AverageTime, s.TotalTime)
+	}
+
+	if s.Cleared[2] != 1 || s.Hits[1][2] != 1 {
+		t.Errorf("Expected the loss in the third bucket and the hit at 1, 2, but got %v and %v", s.Cleared, s.Hits)
+	}
+
+	// A board cleared without winning is in the last bucket
+	if all[1].Cleared[ClearedBuckets-1] != 1 {
+		t.Errorf("Expected the loss in the last bucket, but got %v", all[1].Cleared)
+	}
+
+	if empty := h.StatsOf(SettingsOf(9, 9, 10)); empty.Played != 0 || len(empty.Hits) != 9 {
+		t.Errorf("Expected empty stats of a 9 X 9 board, but got %+v", empty)
+	}
+}
+
+func TestAddGameKeepsLast(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HistoryFileName)
+	settings := SettingsOf(3, 3, 1)
+
+	h := &History{Games: make([]Game, MaxGames)}
+	for i := range h.Games {
+		h.Games[i] = Game{Settings: settings, Clicks: i}
+	}
+
+	if err := h.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := AddGame(path, Game{Settings: settings, Clicks: MaxGames})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The oldest game makes room for the new one
+	if len(h.Games) != MaxGames || h.Games[0].Clicks != 1 || h.Games[MaxGames-1].Clicks != MaxGames {
+		t.Errorf("Expected the last %d games, but got %d from %d to %d", MaxGames, len(h.Games), h.Games[0].Clicks, h.Games[len(h.Games)-1].Clicks)
+	}
+}
+
+func TestAddGameConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HistoryFileName)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			if _, err := AddGame(path, Game{Settings: SettingsOf(3, 3, 1), Clicks: i}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	h, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(h.Games) != 20 {
+		t.Errorf("Expected 20 games, but got %d", len(h.Games))
+	}
+}
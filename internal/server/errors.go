@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// apiError is an error returned as JSON:
+//
+//	{"error":{"code":"already_revealed","message":"cell is already revealed"}}
+type apiError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+var (
+	errNotFound         = &apiError{Status: http.StatusNotFound, Code: "not_found", Message: "game not found"}
+	errMethodNotAllowed = &apiError{Status: http.StatusMethodNotAllowed, Code: "method_not_allowed", Message: "method not allowed"}
+	errTooManyGames     = &apiError{Status: http.StatusServiceUnavailable, Code: "too_many_games", Message: "too many games, try again later"}
+	errDifficultyRange  = &apiError{Status: http.StatusUnprocessableEntity, Code: "difficulty_range", Message: "no board in the difficulty range was found, try a wider range"}
+)
+
+// moveErrors maps the move errors of the engine to API errors
+var moveErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{minesweeper.ErrOutOfBounds, http.StatusBadRequest, "out_of_bounds"},
+	{minesweeper.ErrAlreadyRevealed, http.StatusConflict, "already_revealed"},
+	{minesweeper.ErrCellFlagged, http.StatusConflict, "cell_flagged"},
+	{minesweeper.ErrGameOver, http.StatusConflict, "game_over"},
+}
+
+func invalidRequest(format string, a ...any) *apiError {
+	return &apiError{Status: http.StatusBadRequest, Code: "invalid_request", Message: fmt.Sprintf(format, a...)}
+}
+
+// toAPIError converts an error to the error sent to the client.
+func toAPIError(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for _, moveErr := range moveErrors {
+		if errors.Is(err, moveErr.err) {
+			return &apiError{Status: moveErr.status, Code: moveErr.code, Message: err.Error()}
+		}
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return &apiError{Status: http.StatusServiceUnavailable, Code: "canceled", Message: err.Error()}
+	}
+
+	return &apiError{Status: http.StatusInternalServerError, Code: "internal", Message: "internal server error"}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	apiErr := toAPIError(err)
+	writeJSON(w, apiErr.Status, map[string]any{"error": apiErr})
+}
@@ -0,0 +1,331 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// Types of the messages sent to WebSocket clients
+const (
+	msgSnapshot = "snapshot"
+	msgStarted  = "started"
+	msgCells    = "cells"
+	msgMineHit  = "mineHit"
+	msgWon      = "won"
+	msgPlayers  = "players"
+	msgClosed   = "closed"
+	msgError    = "error"
+)
+
+// Number of messages queued for a client. A client that falls further
+// behind misses messages, it notices the gap in the sequence numbers.
+const clientQueue = 64
+
+// A WebSocket client is pinged every wsPingInterval. A client that sends
+// nothing, not even a pong, for wsReadTimeout or takes longer than
+// wsWriteTimeout to receive a message is dropped, so a dead connection
+// doesn't keep its game.
+const (
+	wsPingInterval = 30 * time.Second
+	wsReadTimeout  = 2 * wsPingInterval
+	wsWriteTimeout = 10 * time.Second
+)
+
+// message is a message sent to WebSocket clients.
+//
+// Every change of a game gets the next sequence number of the game. A
+// snapshot has the sequence number of the last change it includes, so a
+// client that misses a number can ask for a snapshot and continue from it.
+// Errors and the closed message are not numbered.
+type message struct {
+	Seq      uint64      `json:"seq,omitempty"`
+	Type     string      `json:"type"`
+	Player   string      `json:"player,omitempty"`
+	Position *position   `json:"position,omitempty"`
+	Cells    []cellDelta `json:"cells,omitempty"`
+	Counters *counters   `json:"counters,omitempty"`
+	Players  []*player   `json:"players,omitempty"`
+	State    *state      `json:"state,omitempty"`
+	Error    *apiError   `json:"error,omitempty"`
+	Reason   string      `json:"reason,omitempty"`
+}
+
+type position struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// cellDelta is the new character of a cell.
+type cellDelta struct {
+	Row  int    `json:"row"`
+	Col  int    `json:"col"`
+	Cell string `json:"cell"`
+}
+
+// counters are sent with every change of cells.
+type counters struct {
+	Flags    int       `json:"flags"`
+	Revealed int       `json:"revealed"`
+	Status   string    `json:"status"`
+	Players  []*player `json:"players,omitempty"`
+}
+
+// clientRequest is a message sent by a WebSocket client. Type is
+// "snapshot" or a move ("reveal", "flag" or "chord") of the cell at Row, Col.
+// Moves require Row and Col.
+type clientRequest struct {
+	Type string `json:"type"`
+	Row  *int   `json:"row"`
+	Col  *int   `json:"col"`
+}
+
+// client is a WebSocket client of a game. Moves of a client that gave a
+// name are attributed to its player.
+type client struct {
+	send   chan []byte
+	player *minesweeper.Player
+}
+
+// handleEvent is called for every event of the board while the session
+// is held. It records the times of the game and streams the changes.
+func (s *Server) handleEvent(g *game, board *minesweeper.Board, event minesweeper.Event) {
+	var name string
+	if g.mover != nil {
+		name = g.mover.Name
+	}
+
+	switch e := event.(type) {
+	case minesweeper.GameStartedEvent:
+		g.startedAt = s.now()
+		g.broadcast(&message{Type: msgStarted, Player: name, Position: &position{Row: e.Row, Col: e.Col}})
+	case minesweeper.CellsRevealedEvent:
+		cells := make([]cellDelta, len(e.Cells))
+		for i, pos := range e.Cells {
+			cells[i] = cellDelta{Row: pos.Row, Col: pos.Col, Cell: string(board.CellView(pos.Row, pos.Col).Char())}
+		}
+
+		g.broadcast(&message{Type: msgCells, Player: name, Cells: cells, Counters: newCounters(g, board)})
+	case minesweeper.FlagChangedEvent:
+		cell := cellDelta{Row: e.Row, Col: e.Col, Cell: string(board.CellView(e.Row, e.Col).Char())}
+		g.broadcast(&message{Type: msgCells, Player: name, Cells: []cellDelta{cell}, Counters: newCounters(g, board)})
+	case minesweeper.MineHitEvent:
+		g.endedAt = s.now()
+		g.broadcast(&message{Type: msgMineHit, Player: name, Position: &position{Row: e.Row, Col: e.Col}})
+		g.broadcast(&message{Type: msgSnapshot, State: s.newState(g, board)})
+	case minesweeper.GameWonEvent:
+		g.endedAt = s.now()
+		g.broadcast(&message{Type: msgWon})
+		g.broadcast(&message{Type: msgSnapshot, State: s.newState(g, board)})
+	}
+}
+
+// newCounters returns the counters of the game. The statistics of the
+// players are part of them, they change with the cells.
+func newCounters(g *game, board *minesweeper.Board) *counters {
+	return &counters{
+		Flags:    board.FlagsCount(),
+		Revealed: board.CellsRevealed(),
+		Status:   board.Status().String(),
+		Players:  newPlayers(g.team),
+	}
+}
+
+// broadcast numbers the message and queues it for every client. The
+// session must be held.
+func (g *game) broadcast(msg *message) {
+	g.seq++
+	msg.Seq = g.seq
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	for c := range g.clients {
+		c.queue(data)
+	}
+}
+
+// queue queues a message, it is dropped if the client is too far behind.
+func (c *client) queue(data []byte) {
+	select {
+	case c.send <- data:
+	default:
+	}
+}
+
+func (c *client) queueMessage(msg *message) {
+	if data, err := json.Marshal(msg); err == nil {
+		c.queue(data)
+	}
+}
+
+// reply queues a message for one client if it is still connected. A
+// snapshot message gets the current state and sequence number.
+func (s *Server) reply(g *game, c *client, msg *message) {
+	g.session.Do(context.Background(), func(board *minesweeper.Board) error {
+		if _, ok := g.clients[c]; !ok {
+			return nil
+		}
+
+		if msg.Type == msgSnapshot {
+			msg.Seq = g.seq
+			msg.State = s.newState(g, board)
+		}
+
+		c.queueMessage(msg)
+		return nil
+	})
+}
+
+// close disconnects the clients of a game that was deleted or expired.
+func (g *game) close(reason string) {
+	g.session.Do(context.Background(), func(board *minesweeper.Board) error {
+		for c := range g.clients {
+			c.queueMessage(&message{Type: msgClosed, Reason: reason})
+			close(c.send)
+		}
+
+		g.clients = nil
+		g.closed = true
+		return nil
+	})
+}
+
+// handleEvents streams the changes of a game over a WebSocket. The first
+// message is a snapshot. Clients can send moves and ask for a snapshot.
+// With ?name= the client joins the game as a player, the player leaves
+// when the connection is closed.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request, g *game) {
+	conn, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	conn.readTimeout = s.readTimeout
+	conn.writeTimeout = s.writeTimeout
+
+	ctx := context.Background()
+	c := &client{send: make(chan []byte, clientQueue)}
+
+	// Register and send the snapshot at once so no change is missed
+	err = g.session.Do(ctx, func(board *minesweeper.Board) error {
+		if g.closed {
+			return errNotFound
+		}
+
+		if g.clients == nil {
+			g.clients = make(map[*client]struct{})
+		}
+
+		g.clients[c] = struct{}{}
+		c.queueMessage(&message{Seq: g.seq, Type: msgSnapshot, State: s.newState(g, board)})
+
+		if name := strings.TrimSpace(r.URL.Query().Get("name")); name != "" {
+			c.player = g.player(name)
+			if c.player.Left {
+				c.player.Left = false
+				g.broadcast(&message{Type: msgPlayers, Players: newPlayers(g.team)})
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		data, _ := json.Marshal(&message{Type: msgClosed, Reason: err.Error()})
+		conn.WriteText(data)
+		conn.Close()
+		return
+	}
+
+	g.watchers.Add(1)
+	defer g.watchers.Add(-1)
+
+	// Write the queued messages until the client is removed
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		s.writeEvents(conn, c)
+		conn.Close()
+	}()
+
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		var req clientRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			s.reply(g, c, &message{Type: msgError, Error: invalidRequest("invalid JSON message: %v", err)})
+			continue
+		}
+
+		if req.Type == msgSnapshot {
+			s.reply(g, c, &message{Type: msgSnapshot})
+			continue
+		}
+
+		var name string
+		if c.player != nil {
+			name = c.player.Name
+		}
+
+		if req.Row == nil || req.Col == nil {
+			s.reply(g, c, &message{Type: msgError, Error: invalidRequest("row and col are required")})
+			continue
+		}
+
+		if err := g.move(ctx, name, req.Type, *req.Row, *req.Col); err != nil {
+			s.reply(g, c, &message{Type: msgError, Error: toAPIError(err)})
+		}
+	}
+
+	g.session.Do(ctx, func(board *minesweeper.Board) error {
+		if _, ok := g.clients[c]; ok {
+			delete(g.clients, c)
+			close(c.send)
+		}
+
+		if c.player != nil && !g.closed {
+			g.team.Leave(c.player)
+			g.broadcast(&message{Type: msgPlayers, Players: newPlayers(g.team)})
+		}
+
+		return nil
+	})
+
+	<-done
+}
+
+// writeEvents writes the queued messages of a client and pings it between
+// them. It returns when the client is removed or a write fails, closing the
+// connection then ends the reads of handleEvents.
+func (s *Server) writeEvents(conn *wsConn, c *client) {
+	ticker := time.NewTicker(s.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case data, ok := <-c.send:
+			if !ok {
+				return
+			}
+
+			if err := conn.WriteText(data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.Ping(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,555 @@
+// Package server hosts minesweeper games over HTTP.
+//
+// Games are kept in memory and expire when they haven't been used for the
+// TTL of the server. Every game is played through a minesweeper.Session, so
+// requests for the same game are serialized.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// indexHTML is the browser front end, a single page using the API
+//
+//go:embed web/index.html
+var indexHTML []byte
+
+// Default limits of a server
+const (
+	DefaultTTL      = time.Hour
+	DefaultMaxGames = 1000
+	// MaxCells is the largest board that can be created
+	MaxCells = 100 * 100
+	// MaxRatedCells is the largest board that can be created with a
+	// difficulty range, every board generated for it is rated
+	MaxRatedCells = 30 * 30
+)
+
+// Options configure a server. Zero values use the defaults.
+type Options struct {
+	// TTL is how long a game is kept after its last request
+	TTL time.Duration
+	// MaxGames is the number of games kept at once
+	MaxGames int
+}
+
+// Server is an http.Handler serving the game API.
+type Server struct {
+	options Options
+
+	mu    sync.Mutex
+	games map[string]*game
+
+	// now returns the current time, replaced in tests
+	now func() time.Time
+
+	// Timing of WebSocket clients, see wsPingInterval. Shortened in tests.
+	pingInterval time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+// game is a game hosted by the server.
+type game struct {
+	id         string
+	seed       int64
+	difficulty string
+	session    *minesweeper.Session
+	moves      atomic.Int64
+
+	// team holds the players of the game, mover is the player whose move
+	// is being played. Both are guarded by the session.
+	team      *minesweeper.Team
+	mover     *minesweeper.Player
+	createdAt time.Time
+
+	// expiresAt is guarded by the server's mutex
+	expiresAt time.Time
+
+	// Time of the first reveal and the end of the game, guarded by the session
+	startedAt time.Time
+	endedAt   time.Time
+
+	// Difficulty rating of the board, set by the snapshot sent when the game
+	// ends and reused after. Guarded by the session.
+	rating *rating
+
+	// Live updates sent to WebSocket clients, guarded by the session
+	seq     uint64
+	clients map[*client]struct{}
+	closed  bool
+
+	// watchers is the number of connected clients, games with clients don't expire
+	watchers atomic.Int32
+}
+
+// New creates a server without games.
+func New(options Options) *Server {
+	if options.TTL <= 0 {
+		options.TTL = DefaultTTL
+	}
+
+	if options.MaxGames <= 0 {
+		options.MaxGames = DefaultMaxGames
+	}
+
+	return &Server{
+		options: options,
+		games:   make(map[string]*game),
+		now:     time.Now,
+
+		pingInterval: wsPingInterval,
+		readTimeout:  wsReadTimeout,
+		writeTimeout: wsWriteTimeout,
+	}
+}
+
+// ServeHTTP routes the request:
+//
+//	GET    /                    browser front end
+//	POST   /games               create a game
+//	GET    /games               list the games
+//	GET    /games/{id}          state of a game
+//	DELETE /games/{id}          delete a game
+//	POST   /games/{id}/reveal   reveal a cell
+//	POST   /games/{id}/flag     place or remove a flag
+//	POST   /games/{id}/chord    reveal the neighbours of a number
+//	GET    /games/{id}/events   WebSocket with live updates
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.purge()
+
+	if r.URL.Path == "/" || r.URL.Path == "/index.html" {
+		s.handleIndex(w, r)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "games" || len(parts) > 3 {
+		writeError(w, errNotFound)
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodPost:
+			s.handleCreate(w, r)
+		case http.MethodGet:
+			s.handleList(w, r)
+		default:
+			writeError(w, errMethodNotAllowed)
+		}
+
+		return
+	}
+
+	g, ok := s.game(parts[1])
+	if !ok {
+		writeError(w, errNotFound)
+		return
+	}
+
+	if len(parts) == 2 {
+		switch r.Method {
+		case http.MethodGet:
+			s.handleState(w, r, g)
+		case http.MethodDelete:
+			s.handleDelete(w, r, g)
+		default:
+			writeError(w, errMethodNotAllowed)
+		}
+
+		return
+	}
+
+	if parts[2] == "events" {
+		s.handleEvents(w, r, g)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, errMethodNotAllowed)
+		return
+	}
+
+	switch parts[2] {
+	case "reveal", "flag", "chord":
+		s.handleMove(w, r, g, parts[2])
+	default:
+		writeError(w, errNotFound)
+	}
+}
+
+// game returns the game with the id and extends its expiry.
+func (s *Server) game(id string) (*game, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.games[id]
+	if ok {
+		g.expiresAt = s.now().Add(s.options.TTL)
+	}
+
+	return g, ok
+}
+
+// add stores a new game. It returns false if the server is full.
+func (s *Server) add(g *game) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.games) >= s.options.MaxGames {
+		return false
+	}
+
+	g.createdAt = s.now()
+	g.expiresAt = g.createdAt.Add(s.options.TTL)
+	s.games[g.id] = g
+
+	return true
+}
+
+// purge deletes the expired games. Games watched over a WebSocket don't
+// expire.
+func (s *Server) purge() {
+	var expired []*game
+
+	s.mu.Lock()
+	now := s.now()
+	for id, g := range s.games {
+		if !now.Before(g.expiresAt) && g.watchers.Load() == 0 {
+			delete(s.games, id)
+			expired = append(expired, g)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, g := range expired {
+		g.close("game expired")
+	}
+}
+
+// createRequest is the body of POST /games. Difficulty selects a preset,
+// otherwise Rows, Cols and Mines (or Density) set the board.
+type createRequest struct {
+	Difficulty    string  `json:"difficulty"`
+	Rows          int     `json:"rows"`
+	Cols          int     `json:"cols"`
+	Mines         int     `json:"mines"`
+	Density       float64 `json:"density"`
+	Seed          int64   `json:"seed"`
+	MinDifficulty float64 `json:"minDifficulty"`
+	MaxDifficulty float64 `json:"maxDifficulty"`
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if err := decode(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if req.Difficulty != "" && !strings.EqualFold(req.Difficulty, minesweeper.DifficultyCustom) {
+		preset, ok := minesweeper.DifficultyByName(req.Difficulty)
+		if !ok {
+			writeError(w, invalidRequest("unknown difficulty %q, use beginner, intermediate, expert or custom", req.Difficulty))
+			return
+		}
+
+		req.Rows, req.Cols, req.Mines = preset.Rows, preset.Cols, preset.Mines
+	}
+
+	if req.Density != 0 {
+		if req.Density <= 0 || req.Density >= 1 {
+			writeError(w, invalidRequest("density must be between 0 and 1, got %g", req.Density))
+			return
+		}
+
+		req.Mines = minesweeper.MinesForDensity(req.Rows, req.Cols, req.Density)
+	}
+
+	if err := minesweeper.ValidateBoard(req.Rows, req.Cols, req.Mines); err != nil {
+		writeError(w, invalidRequest("%v", err))
+		return
+	}
+
+	if req.Rows*req.Cols > MaxCells {
+		writeError(w, invalidRequest("board has %d cells, at most %d are allowed", req.Rows*req.Cols, MaxCells))
+		return
+	}
+
+	if req.MinDifficulty < 0 || req.MaxDifficulty < 0 {
+		writeError(w, invalidRequest("minDifficulty and maxDifficulty can't be negative"))
+		return
+	}
+
+	if req.MaxDifficulty > 0 && req.MinDifficulty > req.MaxDifficulty {
+		writeError(w, invalidRequest("minDifficulty %g is above maxDifficulty %g", req.MinDifficulty, req.MaxDifficulty))
+		return
+	}
+
+	rated := req.MinDifficulty > 0 || req.MaxDifficulty > 0
+	if rated && req.Rows*req.Cols > MaxRatedCells {
+		writeError(w, invalidRequest("board has %d cells, at most %d are allowed with a difficulty range", req.Rows*req.Cols, MaxRatedCells))
+		return
+	}
+
+	if req.Seed == 0 {
+		req.Seed = time.Now().UnixNano()
+	}
+
+	id, err := newID()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// The boards are generated until the client is gone at the latest
+	board, err := minesweeper.NewBoardContext(r.Context(), req.Rows, req.Cols, req.Mines, &minesweeper.BoardOptions{
+		Seed:          req.Seed,
+		MinDifficulty: req.MinDifficulty,
+		MaxDifficulty: req.MaxDifficulty,
+	}, nil)
+	if err != nil {
+		if errors.Is(err, minesweeper.ErrDifficultyRange) && r.Context().Err() == nil {
+			err = errDifficultyRange
+		}
+
+		writeError(w, err)
+		return
+	}
+
+	g := &game{
+		id:         id,
+		seed:       req.Seed,
+		difficulty: minesweeper.DifficultyOf(req.Rows, req.Cols, req.Mines),
+		session:    minesweeper.NewSession(board),
+		team:       minesweeper.NewTeam(board),
+	}
+
+	board.Subscribe(func(event minesweeper.Event) {
+		s.handleEvent(g, board, event)
+	})
+
+	if !s.add(g) {
+		writeError(w, errTooManyGames)
+		return
+	}
+
+	s.writeState(w, r, g, http.StatusCreated)
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, errMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(indexHTML)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	games := make([]*game, 0, len(s.games))
+	expires := make(map[string]time.Time, len(s.games))
+	for _, g := range s.games {
+		games = append(games, g)
+		expires[g.id] = g.expiresAt
+	}
+	s.mu.Unlock()
+
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].createdAt.Before(games[j].createdAt)
+	})
+
+	summaries := make([]*summary, 0, len(games))
+	for _, g := range games {
+		view, err := g.session.PlayerView(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		summaries = append(summaries, &summary{
+			ID:         g.id,
+			Rows:       view.Rows,
+			Cols:       view.Cols,
+			Mines:      view.NumMines,
+			Difficulty: g.difficulty,
+			Status:     view.Status.String(),
+			CreatedAt:  g.createdAt,
+			ExpiresAt:  expires[g.id],
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"games": summaries})
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request, g *game) {
+	s.writeState(w, r, g, http.StatusOK)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request, g *game) {
+	s.mu.Lock()
+	delete(s.games, g.id)
+	s.mu.Unlock()
+
+	g.close("game deleted")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// moveRequest is the body of a move. Row and Col are 0-based. The move is
+// attributed to Player if it is set, the player joins the game with the
+// first move.
+type moveRequest struct {
+	Row    *int   `json:"row"`
+	Col    *int   `json:"col"`
+	Player string `json:"player"`
+}
+
+func (s *Server) handleMove(w http.ResponseWriter, r *http.Request, g *game, action string) {
+	var req moveRequest
+	if err := decode(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if req.Row == nil || req.Col == nil {
+		writeError(w, invalidRequest("row and col are required"))
+		return
+	}
+
+	if err := g.move(r.Context(), req.Player, action, *req.Row, *req.Col); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.writeState(w, r, g, http.StatusOK)
+}
+
+// move plays a move of the named player: reveal, flag or chord. Moves
+// without a name are not attributed to anyone.
+func (g *game) move(ctx context.Context, name, action string, row, col int) error {
+	var play func(board *minesweeper.Board) error
+
+	switch action {
+	case "reveal":
+		play = func(board *minesweeper.Board) error {
+			_, err := board.TryReveal(row, col)
+			return err
+		}
+	case "flag":
+		play = func(board *minesweeper.Board) error {
+			return board.TryToggleFlag(row, col)
+		}
+	case "chord":
+		play = func(board *minesweeper.Board) error {
+			_, err := board.TryChord(row, col)
+			return err
+		}
+	default:
+		return invalidRequest("unknown move %q, use reveal, flag or chord", action)
+	}
+
+	err := g.session.Do(ctx, func(board *minesweeper.Board) error {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return play(board)
+		}
+
+		g.mover = g.player(name)
+		defer func() {
+			g.mover = nil
+		}()
+
+		return g.team.Play(g.mover, func() error {
+			return play(board)
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	g.moves.Add(1)
+	return nil
+}
+
+// player returns the player with the name, a new player joins the game.
+// The session must be held.
+func (g *game) player(name string) *minesweeper.Player {
+	p := g.team.Player(name)
+	if p == nil {
+		p = g.team.Join(name)
+		g.broadcast(&message{Type: msgPlayers, Players: newPlayers(g.team)})
+	}
+
+	return p
+}
+
+func (s *Server) writeState(w http.ResponseWriter, r *http.Request, g *game, status int) {
+	var st *state
+	err := g.session.Do(r.Context(), func(board *minesweeper.Board) error {
+		st = s.newState(g, board)
+		return nil
+	})
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, status, st)
+}
+
+// newState builds the state of the game, the session must be held.
+func (s *Server) newState(g *game, board *minesweeper.Board) *state {
+	s.mu.Lock()
+	expiresAt := g.expiresAt
+	s.mu.Unlock()
+
+	return newState(g, board, expiresAt)
+}
+
+// decode decodes the JSON body of the request. Unknown fields are rejected
+// to catch typos.
+func decode(r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<16))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return invalidRequest("invalid JSON body: %v", err)
+	}
+
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// newID returns a random game id.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
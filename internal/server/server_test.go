@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func request(t *testing.T, s *Server, method, path, body string) (int, map[string]any) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+	var result map[string]any
+	if w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Expected a JSON response to %s %s, but got %q", method, path, w.Body.String())
+		}
+	}
+
+	return w.Code, result
+}
+
+func errorCode(result map[string]any) string {
+	apiErr, _ := result["error"].(map[string]any)
+	code, _ := apiErr["code"].(string)
+	return code
+}
+
+func TestServerGame(t *testing.T) {
+	s := New(Options{})
+
+	status, game := request(t, s, http.MethodPost, "/games", `{"rows":5,"cols":5,"mines":3,"seed":7}`)
+	if status != http.StatusCreated {
+		t.Fatalf("Expected status %d, but got %d: %v", http.StatusCreated, status, game)
+	}
+
+	if _, ok := game["seed"]; ok {
+		t.Errorf("Expected the seed to be hidden while playing")
+	}
+
+	id := game["id"].(string)
+
+	status, game = request(t, s, http.MethodPost, "/games/"+id+"/reveal", `{"row":0,"col":0}`)
+	if status != http.StatusOK || game["revealed"].(float64) < 1 || game["moves"].(float64) != 1 {
+		t.Errorf("Expected a revealed cell after one move, but got %d: %v", status, game)
+	}
+
+	if _, ok := game["startedAt"]; !ok {
+		t.Errorf("Expected the game to be started after the first reveal")
+	}
+
+	for _, row := range game["board"].([]any) {
+		if strings.Contains(row.(string), "*") {
+			t.Errorf("Expected no mines on the board while playing, but got %q", row)
+		}
+	}
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{http.MethodPost, "/games/" + id + "/reveal", `{"row":0,"col":0}`, http.StatusConflict, "already_revealed"},
+		{http.MethodPost, "/games/" + id + "/flag", `{"row":5,"col":0}`, http.StatusBadRequest, "out_of_bounds"},
+		{http.MethodPost, "/games/" + id + "/flag", `{"row":1}`, http.StatusBadRequest, "invalid_request"},
+		{http.MethodPost, "/games/" + id + "/jump", `{}`, http.StatusNotFound, "not_found"},
+		{http.MethodGet, "/games/unknown", ``, http.StatusNotFound, "not_found"},
+		{http.MethodPut, "/games", ``, http.StatusMethodNotAllowed, "method_not_allowed"},
+		{http.MethodPost, "/games", `{"rows":5,"cols":5,"mines":25}`, http.StatusBadRequest, "invalid_request"},
+		{http.MethodPost, "/games", `{"difficulty":"insane"}`, http.StatusBadRequest, "invalid_request"},
+		{http.MethodPost, "/games", `{"rowz":5}`, http.StatusBadRequest, "invalid_request"},
+		{http.MethodPost, "/games", `{"rows":5,"cols":5,"mines":3,"minDifficulty":-1}`, http.StatusBadRequest, "invalid_request"},
+		{http.MethodPost, "/games", `{"rows":5,"cols":5,"mines":3,"minDifficulty":3,"maxDifficulty":2}`, http.StatusBadRequest, "invalid_request"},
+		{http.MethodPost, "/games", `{"rows":31,"cols":30,"mines":3,"maxDifficulty":5}`, http.StatusBadRequest, "invalid_request"},
+		{http.MethodPost, "/games", `{"rows":5,"cols":5,"mines":3,"minDifficulty":1000}`, http.StatusUnprocessableEntity, "difficulty_range"},
+	}
+
+	for _, test := range tests {
+		status, result := request(t, s, test.method, test.path, test.body)
+		if status != test.status || errorCode(result) != test.code {
+			t.Errorf("Expected %s %s to fail with %d %s, but got %d %v", test.method, test.path, test.status, test.code, status, result)
+		}
+	}
+
+	status, list := request(t, s, http.MethodGet, "/games", "")
+	if games := list["games"].([]any); status != http.StatusOK || len(games) != 1 {
+		t.Errorf("Expected one game in the list, but got %d: %v", status, list)
+	}
+
+	if status, _ := request(t, s, http.MethodDelete, "/games/"+id, ""); status != http.StatusNoContent {
+		t.Errorf("Expected status %d after delete, but got %d", http.StatusNoContent, status)
+	}
+
+	if status, _ := request(t, s, http.MethodGet, "/games/"+id, ""); status != http.StatusNotFound {
+		t.Errorf("Expected deleted game to be gone, but got %d", status)
+	}
+}
+
+func TestServerCreateCanceled(t *testing.T) {
+	s := New(Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A client that is gone stops the search for a board in range
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/games", strings.NewReader(`{"rows":30,"cols":30,"mines":100,"minDifficulty":1000}`)).WithContext(ctx))
+
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"canceled"`) {
+		t.Errorf("Expected the request to be canceled, but got %d %s", w.Code, w.Body.String())
+	}
+
+	if status, list := request(t, s, http.MethodGet, "/games", ""); status != http.StatusOK || len(list["games"].([]any)) != 0 {
+		t.Errorf("Expected no game, but got %d: %v", status, list)
+	}
+}
+
+func TestServerExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	s := New(Options{TTL: time.Minute, MaxGames: 1})
+	s.now = func() time.Time { return now }
+
+	_, game := request(t, s, http.MethodPost, "/games", `{"difficulty":"beginner"}`)
+	id := game["id"].(string)
+
+	if status, result := request(t, s, http.MethodPost, "/games", `{"difficulty":"beginner"}`); status != http.StatusServiceUnavailable || errorCode(result) != "too_many_games" {
+		t.Errorf("Expected the server to be full, but got %d %v", status, result)
+	}
+
+	// Using the game extends its expiry
+	now = now.Add(50 * time.Second)
+	if status, _ := request(t, s, http.MethodGet, "/games/"+id, ""); status != http.StatusOK {
+		t.Errorf("Expected the game to exist, but got %d", status)
+	}
+
+	now = now.Add(50 * time.Second)
+	if status, _ := request(t, s, http.MethodGet, "/games/"+id, ""); status != http.StatusOK {
+		t.Errorf("Expected the game to exist after it was used, but got %d", status)
+	}
+
+	now = now.Add(time.Minute)
+	if status, _ := request(t, s, http.MethodGet, "/games/"+id, ""); status != http.StatusNotFound {
+		t.Errorf("Expected the game to expire, but got %d", status)
+	}
+}
+
+func TestServerIndex(t *testing.T) {
+	s := New(Options{})
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || !strings.Contains(w.Body.String(), "/games") {
+		t.Errorf("Expected the front end, but got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestServerPlayers(t *testing.T) {
+	s := New(Options{})
+
+	_, game := request(t, s, http.MethodPost, "/games", `{"rows":5,"cols":5,"mines":3,"seed":7}`)
+	id := game["id"].(string)
+
+	request(t, s, http.MethodPost, "/games/"+id+"/reveal", `{"row":0,"col":0,"player":"alice"}`)
+	request(t, s, http.MethodPost, "/games/"+id+"/flag", `{"row":4,"col":4,"player":"bob"}`)
+	_, game = request(t, s, http.MethodPost, "/games/"+id+"/flag", `{"row":4,"col":3}`)
+
+	players, _ := game["players"].([]any)
+	if len(players) != 2 {
+		t.Fatalf("Expected two players, but got %v", game["players"])
+	}
+
+	alice := players[0].(map[string]any)
+	bob := players[1].(map[string]any)
+
+	if alice["name"] != "alice" || alice["revealed"].(float64) < 1 || alice["moves"].(float64) != 1 {
+		t.Errorf("Expected alice to have revealed cells, but got %v", alice)
+	}
+
+	if bob["name"] != "bob" || bob["flags"].(float64) != 1 || bob["revealed"].(float64) != 0 {
+		t.Errorf("Expected bob to have one flag, but got %v", bob)
+	}
+
+	if alice["color"] == bob["color"] || !strings.HasPrefix(alice["color"].(string), "#") {
+		t.Errorf("Expected different colors, but got %v and %v", alice["color"], bob["color"])
+	}
+
+	// Moves without a player count for the game only
+	if game["moves"].(float64) != 3 {
+		t.Errorf("Expected 3 moves, but got %v", game["moves"])
+	}
+}
+
+func TestServerRating(t *testing.T) {
+	s := New(Options{})
+
+	_, game := request(t, s, http.MethodPost, "/games", `{"rows":3,"cols":3,"mines":1,"seed":7}`)
+	id := game["id"].(string)
+
+	if _, ok := game["rating"]; ok {
+		t.Errorf("Expected the rating to be hidden while playing")
+	}
+
+	g := s.games[id]
+
+	var mine position
+	g.session.Do(context.Background(), func(board *minesweeper.Board) error {
+		for r := range board.Cells {
+			for c := range board.Cells[r] {
+				if board.Cells[r][c].IsMine {
+					mine = position{Row: r, Col: c}
+				}
+			}
+		}
+
+		return nil
+	})
+
+	request(t, s, http.MethodPost, "/games/"+id+"/reveal", fmt.Sprintf(`{"row":%d,"col":%d}`, mine.Row, mine.Col))
+
+	_, first := request(t, s, http.MethodGet, "/games/"+id, "")
+	_, second := request(t, s, http.MethodGet, "/games/"+id, "")
+
+	if first["status"] == "playing" {
+		t.Fatalf("Expected the game to be over, but got %v", first)
+	}
+
+	if first["rating"] == nil || fmt.Sprint(first["rating"]) != fmt.Sprint(second["rating"]) {
+		t.Errorf("Expected the same rating in every state, but got %v and %v", first["rating"], second["rating"])
+	}
+
+	// The rating is kept on the game once it is over
+	g.session.Do(context.Background(), func(board *minesweeper.Board) error {
+		if g.rating == nil {
+			t.Errorf("Expected the rating to be kept on the game")
+		}
+
+		return nil
+	})
+}
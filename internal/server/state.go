@@ -0,0 +1,140 @@
+package server
+
+import (
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// state is the state of a game as seen by the player.
+//
+// Board holds one string per row with the characters of
+// minesweeper.CellView: '.' hidden, 'F' flagged, '0'-'8' revealed and '*' a
+// mine. Mines are only shown once
+// the game is over, the seed and rating too since they give away the mines.
+type state struct {
+	ID         string     `json:"id"`
+	Rows       int        `json:"rows"`
+	Cols       int        `json:"cols"`
+	Mines      int        `json:"mines"`
+	Difficulty string     `json:"difficulty"`
+	Status     string     `json:"status"`
+	Flags      int        `json:"flags"`
+	Revealed   int        `json:"revealed"`
+	Hidden     int        `json:"hidden"`
+	Moves      int64      `json:"moves"`
+	Board      []string   `json:"board"`
+	Players    []*player  `json:"players,omitempty"`
+	Seed       int64      `json:"seed,omitempty"`
+	Rating     *rating    `json:"rating,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	EndedAt    *time.Time `json:"endedAt,omitempty"`
+}
+
+// rating is the difficulty rating of a board, see minesweeper.Rating.
+type rating struct {
+	Score       float64 `json:"score"`
+	BV3         int     `json:"bv3"`
+	Openings    int     `json:"openings"`
+	Guesses     int     `json:"guesses"`
+	HardestRule string  `json:"hardestRule"`
+}
+
+// player is a player of a game with the cells the player cleared. Color
+// is the color of the player's cursor as #rrggbb.
+type player struct {
+	Name     string   `json:"name"`
+	Color    string   `json:"color"`
+	Cursor   position `json:"cursor"`
+	Moves    int      `json:"moves"`
+	Revealed int      `json:"revealed"`
+	Flags    int      `json:"flags"`
+	HitMine  bool     `json:"hitMine,omitempty"`
+	Left     bool     `json:"left,omitempty"`
+}
+
+// summary is a game in the list of games.
+type summary struct {
+	ID         string    `json:"id"`
+	Rows       int       `json:"rows"`
+	Cols       int       `json:"cols"`
+	Mines      int       `json:"mines"`
+	Difficulty string    `json:"difficulty"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// newState builds the state of the game, the session must be held.
+func newState(g *game, board *minesweeper.Board, expiresAt time.Time) *state {
+	view := board.PlayerView()
+	gameOver := view.Status != minesweeper.StatusPlaying
+
+	s := &state{
+		ID:         g.id,
+		Rows:       view.Rows,
+		Cols:       view.Cols,
+		Mines:      view.NumMines,
+		Difficulty: g.difficulty,
+		Status:     view.Status.String(),
+		Flags:      view.Flags,
+		Revealed:   view.Revealed,
+		Hidden:     view.Hidden,
+		Moves:      g.moves.Load(),
+		Board:      view.Lines(),
+		Players:    newPlayers(g.team),
+		CreatedAt:  g.createdAt,
+		ExpiresAt:  expiresAt,
+	}
+
+	if !g.startedAt.IsZero() {
+		startedAt := g.startedAt
+		s.StartedAt = &startedAt
+	}
+
+	if !g.endedAt.IsZero() {
+		endedAt := g.endedAt
+		s.EndedAt = &endedAt
+	}
+
+	if gameOver {
+		s.Seed = g.seed
+
+		// Rating solves the board, it is done once for all the states
+		if g.rating == nil {
+			r := minesweeper.Rate(board)
+			g.rating = &rating{
+				Score:       r.Score,
+				BV3:         r.BV3,
+				Openings:    r.Openings,
+				Guesses:     r.Guesses,
+				HardestRule: r.HardestRule.String(),
+			}
+		}
+
+		s.Rating = g.rating
+	}
+
+	return s
+}
+
+// newPlayers returns the players of the team, the session must be held.
+func newPlayers(team *minesweeper.Team) []*player {
+	var players []*player
+	for _, p := range team.Players() {
+		players = append(players, &player{
+			Name:     p.Name,
+			Color:    p.Color.Hex(),
+			Cursor:   position{Row: p.Cursor.Row, Col: p.Cursor.Col},
+			Moves:    p.Moves,
+			Revealed: p.Revealed,
+			Flags:    p.Flags,
+			HitMine:  p.HitMine,
+			Left:     p.Left,
+		})
+	}
+
+	return players
+}
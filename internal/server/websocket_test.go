@@ -0,0 +1,235 @@
+package server
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dialWebSocket opens a client connection to the path on addr.
+func dialWebSocket(addr, path string) (*wsConn, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", path, addr, key)
+
+	r := bufio.NewReader(conn)
+	response, err := http.ReadResponse(r, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake failed: %s", response.Status)
+	}
+
+	return &wsConn{conn: conn, r: r, client: true}, nil
+}
+
+func readMessage(t *testing.T, conn *wsConn) *message {
+	t.Helper()
+
+	conn.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("Expected a message, but got %v", err)
+	}
+
+	var msg message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("Expected a JSON message, but got %q", data)
+	}
+
+	return &msg
+}
+
+func TestAcceptKey(t *testing.T) {
+	// Example of RFC 6455
+	if key := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); key != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Expected accept key s3pPLMBiTxaQ9kYGzzhZRbK+xOo=, but got %s", key)
+	}
+}
+
+func TestWebSocketEvents(t *testing.T) {
+	srv := httptest.NewServer(New(Options{}))
+	defer srv.Close()
+
+	response, err := http.Post(srv.URL+"/games", "application/json", strings.NewReader(`{"rows":5,"cols":5,"mines":3,"seed":7}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var game state
+	json.NewDecoder(response.Body).Decode(&game)
+	response.Body.Close()
+
+	conn, err := dialWebSocket(srv.Listener.Addr().String(), "/games/"+game.ID+"/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if msg := readMessage(t, conn); msg.Type != msgSnapshot || msg.Seq != 0 || msg.State.ID != game.ID {
+		t.Fatalf("Expected a snapshot first, but got %+v", msg)
+	}
+
+	// Moves over HTTP are streamed too
+	response, err = http.Post(srv.URL+"/games/"+game.ID+"/reveal", "application/json", strings.NewReader(`{"row":0,"col":0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if msg := readMessage(t, conn); msg.Type != msgStarted || msg.Seq != 1 || msg.Position.Row != 0 {
+		t.Errorf("Expected the game to start, but got %+v", msg)
+	}
+
+	if msg := readMessage(t, conn); msg.Type != msgCells || msg.Seq != 2 || len(msg.Cells) != 1 || msg.Counters.Revealed != 1 {
+		t.Errorf("Expected one revealed cell, but got %+v", msg)
+	}
+
+	conn.WriteText([]byte(`{"type":"flag","row":4,"col":4}`))
+	if msg := readMessage(t, conn); msg.Type != msgCells || msg.Seq != 3 || msg.Cells[0].Cell != "F" || msg.Counters.Flags != 1 {
+		t.Errorf("Expected a flag, but got %+v", msg)
+	}
+
+	conn.WriteText([]byte(`{"type":"reveal","row":0,"col":0}`))
+	if msg := readMessage(t, conn); msg.Type != msgError || msg.Seq != 0 || msg.Error.Code != "already_revealed" {
+		t.Errorf("Expected an error, but got %+v", msg)
+	}
+
+	// A move needs both the row and the column
+	conn.WriteText([]byte(`{"type":"reveal","row":1}`))
+	if msg := readMessage(t, conn); msg.Type != msgError || msg.Error.Code != "invalid_request" {
+		t.Errorf("Expected an invalid request, but got %+v", msg)
+	}
+
+	conn.WriteText([]byte(`{"type":"snapshot"}`))
+	if msg := readMessage(t, conn); msg.Type != msgSnapshot || msg.Seq != 3 || msg.State.Flags != 1 {
+		t.Errorf("Expected a snapshot at 3, but got %+v", msg)
+	}
+
+	request, _ := http.NewRequest(http.MethodDelete, srv.URL+"/games/"+game.ID, nil)
+	response, err = http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if msg := readMessage(t, conn); msg.Type != msgClosed {
+		t.Errorf("Expected the game to be closed, but got %+v", msg)
+	}
+}
+
+func TestWebSocketPlayer(t *testing.T) {
+	srv := httptest.NewServer(New(Options{}))
+	defer srv.Close()
+
+	response, err := http.Post(srv.URL+"/games", "application/json", strings.NewReader(`{"rows":5,"cols":5,"mines":3,"seed":7}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var game state
+	json.NewDecoder(response.Body).Decode(&game)
+	response.Body.Close()
+
+	conn, err := dialWebSocket(srv.Listener.Addr().String(), "/games/"+game.ID+"/events?name=carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	readMessage(t, conn)
+
+	if msg := readMessage(t, conn); msg.Type != msgPlayers || len(msg.Players) != 1 || msg.Players[0].Name != "carol" {
+		t.Fatalf("Expected carol to join, but got %+v", msg)
+	}
+
+	conn.WriteText([]byte(`{"type":"flag","row":4,"col":4}`))
+	msg := readMessage(t, conn)
+	if msg.Type != msgCells || msg.Player != "carol" || msg.Counters.Players[0].Flags != 1 {
+		t.Errorf("Expected a flag of carol, but got %+v", msg)
+	}
+}
+
+func TestWebSocketDeadClient(t *testing.T) {
+	s := New(Options{})
+	s.pingInterval = 20 * time.Millisecond
+	s.readTimeout = 200 * time.Millisecond
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	response, err := http.Post(srv.URL+"/games", "application/json", strings.NewReader(`{"rows":5,"cols":5,"mines":3,"seed":7}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var game state
+	json.NewDecoder(response.Body).Decode(&game)
+	response.Body.Close()
+
+	s.mu.Lock()
+	g := s.games[game.ID]
+	s.mu.Unlock()
+
+	// The live client reads and so answers the pings, the dead one doesn't
+	alive, err := dialWebSocket(srv.Listener.Addr().String(), "/games/"+game.ID+"/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alive.Close()
+
+	go func() {
+		for {
+			if _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	dead, err := dialWebSocket(srv.Listener.Addr().String(), "/games/"+game.ID+"/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dead.Close()
+
+	// Clients are counted before their snapshot is sent
+	readMessage(t, dead)
+	if n := g.watchers.Load(); n != 2 {
+		t.Fatalf("Expected two clients, but got %d", n)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for g.watchers.Load() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the dead client to be dropped, but the game has %d clients", g.watchers.Load())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The live client is kept past the read timeout
+	time.Sleep(2 * s.readTimeout)
+	if n := g.watchers.Load(); n != 1 {
+		t.Errorf("Expected the live client to be kept, but the game has %d clients", n)
+	}
+}
@@ -30,6 +30,10 @@ func FormatDuration(d time.Duration) string {
 }
 
 func FormatPercentageBar(percentage float64, width int) string {
+	if width < 1 {
+		width = 1
+	}
+
 	filledWidth := int(percentage * float64(width))
 	filled := strings.Repeat("=", filledWidth)
 
@@ -38,3 +42,37 @@ func FormatPercentageBar(percentage float64, width int) string {
 
 	return fmt.Sprintf("[%s%s] %.1f%%", filled, unfilled, percentage*100)
 }
+
+// Characters of sparklines from low to high
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// FormatSparkline returns a character per value, higher values are higher
+// bars.
+func FormatSparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	low, high := values[0], values[0]
+	for _, v := range values {
+		if v < low {
+			low = v
+		}
+
+		if v > high {
+			high = v
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if high > low {
+			i = int((v - low) / (high - low) * float64(len(sparks)-1))
+		}
+
+		b.WriteRune(sparks[i])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package minesweeper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Coordinates is the scheme used to label rows and columns.
+type Coordinates int
+
+const (
+	// CoordinatesNumeric labels rows and columns with numbers.
+	CoordinatesNumeric Coordinates = iota
+	// CoordinatesChess labels columns with letters (A..Z, AA..) and rows with
+	// numbers. A cell is written as the column followed by the row ("C7").
+	CoordinatesChess
+)
+
+func (c Coordinates) String() string {
+	switch c {
+	case CoordinatesNumeric:
+		return "numeric"
+	case CoordinatesChess:
+		return "chess"
+	}
+
+	return "unknown"
+}
+
+// ParseCoordinates parses "numeric" or "chess".
+func ParseCoordinates(s string) (Coordinates, error) {
+	switch strings.ToLower(s) {
+	case "numeric":
+		return CoordinatesNumeric, nil
+	case "chess":
+		return CoordinatesChess, nil
+	}
+
+	return CoordinatesNumeric, fmt.Errorf("unknown coordinates %q, use numeric or chess", s)
+}
+
+// ColumnLetters returns the letters of the 0-based column: A..Z, AA..AZ, BA..
+func ColumnLetters(col int) string {
+	var letters []byte
+
+	for col >= 0 {
+		letters = append([]byte{byte('A' + col%26)}, letters...)
+		col = col/26 - 1
+	}
+
+	return string(letters)
+}
+
+// ParseColumnLetters returns the 0-based column of the letters, ignoring case.
+func ParseColumnLetters(s string) (int, bool) {
+	if s == "" {
+		return 0, false
+	}
+
+	col := 0
+	for _, r := range strings.ToUpper(s) {
+		if r < 'A' || r > 'Z' {
+			return 0, false
+		}
+
+		col = col*26 + int(r-'A') + 1
+	}
+
+	return col - 1, true
+}
+
+// coordinates returns the coordinate scheme of the display options.
+func (b *Board) coordinates() Coordinates {
+	if b.DisplayOptions.Coordinates == nil {
+		return CoordinatesNumeric
+	}
+
+	return *b.DisplayOptions.Coordinates
+}
+
+func (b *Board) startIndex() int {
+	if b.DisplayOptions.StartIndex == nil {
+		return dStartIndex
+	}
+
+	return *b.DisplayOptions.StartIndex
+}
+
+// RowLabel returns the label of the 0-based row.
+func (b *Board) RowLabel(row int) string {
+	return strconv.Itoa(row + b.startIndex())
+}
+
+// ColLabel returns the label of the 0-based column.
+func (b *Board) ColLabel(col int) string {
+	if b.coordinates() == CoordinatesChess {
+		return ColumnLetters(col)
+	}
+
+	return strconv.Itoa(col + b.startIndex())
+}
+
+// FormatCell returns the name of the cell as the player types it, "C7" with
+// chess coordinates and "7 3" (row, col) otherwise.
+func (b *Board) FormatCell(row, col int) string {
+	if b.coordinates() == CoordinatesChess {
+		return b.ColLabel(col) + b.RowLabel(row)
+	}
+
+	return b.RowLabel(row) + " " + b.ColLabel(col)
+}
+
+// ParseCell parses a cell written with chess coordinates ("C7", "ab12").
+// It returns ErrOutOfBounds if the cell is not on the board.
+func (b *Board) ParseCell(s string) (Position, error) {
+	split := strings.IndexFunc(s, func(r rune) bool {
+		return r >= '0' && r <= '9'
+	})
+
+	if split <= 0 {
+		return Position{}, fmt.Errorf("invalid cell %q, use a column letter and a row number like C7", s)
+	}
+
+	col, ok := ParseColumnLetters(s[:split])
+	if !ok {
+		return Position{}, fmt.Errorf("invalid column %q in cell %q", s[:split], s)
+	}
+
+	row, err := strconv.Atoi(s[split:])
+	if err != nil {
+		return Position{}, fmt.Errorf("invalid row %q in cell %q", s[split:], s)
+	}
+
+	pos := Position{Row: row - b.startIndex(), Col: col}
+	if pos.Row < 0 || pos.Row >= b.Rows || pos.Col < 0 || pos.Col >= b.Cols {
+		return pos, fmt.Errorf("%s: %w", s, ErrOutOfBounds)
+	}
+
+	return pos, nil
+}
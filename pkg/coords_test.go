@@ -0,0 +1,50 @@
+package minesweeper_test
+
+import (
+	"errors"
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestColumnLetters(t *testing.T) {
+	tests := map[int]string{0: "A", 2: "C", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"}
+
+	for col, letters := range tests {
+		if got := minesweeper.ColumnLetters(col); got != letters {
+			t.Errorf("Expected column %d to be %s, but got %s", col, letters, got)
+		}
+
+		if got, ok := minesweeper.ParseColumnLetters(letters); !ok || got != col {
+			t.Errorf("Expected %s to be column %d, but got %d", letters, col, got)
+		}
+	}
+
+	if _, ok := minesweeper.ParseColumnLetters("A1"); ok {
+		t.Errorf("Expected A1 to be invalid letters")
+	}
+}
+
+func TestParseCell(t *testing.T) {
+	coordinates := minesweeper.CoordinatesChess
+	board := minesweeper.NewBoard(20, 30, 10, &minesweeper.BoardOptions{Seed: 1}, &minesweeper.DisplayOptions{Coordinates: &coordinates})
+
+	pos, err := board.ParseCell("ab12")
+	if err != nil || pos.Row != 11 || pos.Col != 27 {
+		t.Errorf("Expected ab12 to be (11, 27), but got (%d, %d) %v", pos.Row, pos.Col, err)
+	}
+
+	if name := board.FormatCell(6, 2); name != "C7" {
+		t.Errorf("Expected (6, 2) to be C7, but got %s", name)
+	}
+
+	if _, err := board.ParseCell("AE1"); !errors.Is(err, minesweeper.ErrOutOfBounds) {
+		t.Errorf("Expected AE1 to be out of bounds, but got %v", err)
+	}
+
+	for _, invalid := range []string{"", "7", "C", "7C", "C7x"} {
+		if _, err := board.ParseCell(invalid); err == nil {
+			t.Errorf("Expected %q to be invalid", invalid)
+		}
+	}
+}
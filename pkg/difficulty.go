@@ -0,0 +1,85 @@
+package minesweeper
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Difficulty is a named board configuration.
+type Difficulty struct {
+	Name  string
+	Rows  int
+	Cols  int
+	Mines int
+}
+
+// Name of the difficulty used for boards that don't match a preset
+const DifficultyCustom = "custom"
+
+// Difficulty presets
+var (
+	Beginner     = Difficulty{Name: "beginner", Rows: 9, Cols: 9, Mines: 10}
+	Intermediate = Difficulty{Name: "intermediate", Rows: 16, Cols: 16, Mines: 40}
+	Expert       = Difficulty{Name: "expert", Rows: 16, Cols: 30, Mines: 99}
+)
+
+// Difficulties lists the presets from easiest to hardest.
+var Difficulties = []Difficulty{Beginner, Intermediate, Expert}
+
+// DifficultyByName returns the preset with the given name (case insensitive).
+func DifficultyByName(name string) (Difficulty, bool) {
+	for _, d := range Difficulties {
+		if strings.EqualFold(d.Name, name) {
+			return d, true
+		}
+	}
+
+	return Difficulty{}, false
+}
+
+// DifficultyOf returns the name of the preset matching the board
+// configuration, or DifficultyCustom if none match.
+func DifficultyOf(rows, cols, mines int) string {
+	for _, d := range Difficulties {
+		if d.Rows == rows && d.Cols == cols && d.Mines == mines {
+			return d.Name
+		}
+	}
+
+	return DifficultyCustom
+}
+
+// Density returns the share of cells that are mines.
+func (d Difficulty) Density() float64 {
+	return float64(d.Mines) / float64(d.Rows*d.Cols)
+}
+
+func (d Difficulty) String() string {
+	return fmt.Sprintf("%s (%d X %d, %d mines)", d.Name, d.Rows, d.Cols, d.Mines)
+}
+
+// MinesForDensity returns the number of mines a board of rows x cols needs
+// to get the given density, rounded to the nearest mine.
+func MinesForDensity(rows, cols int, density float64) int {
+	return int(math.Round(float64(rows*cols) * density))
+}
+
+// ValidateBoard checks that a board with the given size and number of mines
+// can be generated. At least one cell has to be free of mines.
+func ValidateBoard(rows, cols, mines int) error {
+	if rows < 1 || cols < 1 {
+		return fmt.Errorf("board must have at least 1 row and 1 column, got %d X %d", rows, cols)
+	}
+
+	if mines < 0 {
+		return fmt.Errorf("number of mines can't be negative, got %d", mines)
+	}
+
+	cells := rows * cols
+	if mines >= cells {
+		return fmt.Errorf("%d mines don't fit on a %d X %d board (%d cells), use at most %d mines (%.1f%% density)", mines, rows, cols, cells, cells-1, float64(cells-1)/float64(cells)*100)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package minesweeper_test
+
+import (
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestDifficultyByName(t *testing.T) {
+	difficulty, ok := minesweeper.DifficultyByName("Expert")
+	if !ok {
+		t.Fatal("Expected expert to be a preset")
+	}
+
+	if difficulty != minesweeper.Expert {
+		t.Errorf("Expected %v, but got %v", minesweeper.Expert, difficulty)
+	}
+
+	if _, ok := minesweeper.DifficultyByName("impossible"); ok {
+		t.Error("Expected impossible not to be a preset")
+	}
+}
+
+func TestDifficultyOf(t *testing.T) {
+	if name := minesweeper.DifficultyOf(16, 16, 40); name != minesweeper.Intermediate.Name {
+		t.Errorf("Expected difficulty to be %s, but got %s", minesweeper.Intermediate.Name, name)
+	}
+
+	if name := minesweeper.DifficultyOf(10, 10, 10); name != minesweeper.DifficultyCustom {
+		t.Errorf("Expected difficulty to be %s, but got %s", minesweeper.DifficultyCustom, name)
+	}
+}
+
+func TestMinesForDensity(t *testing.T) {
+	mines := minesweeper.MinesForDensity(16, 30, 0.20625)
+	if mines != minesweeper.Expert.Mines {
+		t.Errorf("Expected mines to be %d, but got %d", minesweeper.Expert.Mines, mines)
+	}
+}
+
+func TestValidateBoard(t *testing.T) {
+	if err := minesweeper.ValidateBoard(10, 10, 99); err != nil {
+		t.Errorf("Expected 99 mines on a 10 X 10 board to be valid, but got %v", err)
+	}
+
+	if err := minesweeper.ValidateBoard(10, 10, 100); err == nil {
+		t.Error("Expected 100 mines on a 10 X 10 board to be invalid")
+	}
+
+	if err := minesweeper.ValidateBoard(10, 10, 0); err != nil {
+		t.Errorf("Expected a board without mines to be valid, but got %v", err)
+	}
+
+	if err := minesweeper.ValidateBoard(10, 10, -1); err == nil {
+		t.Error("Expected -1 mines to be invalid")
+	}
+
+	if err := minesweeper.ValidateBoard(0, 10, 1); err == nil {
+		t.Error("Expected a board without rows to be invalid")
+	}
+}
@@ -0,0 +1,176 @@
+package minesweeper
+
+// Duel is the two player game "Flags": the players take turns to find the
+// mines. Revealing a mine claims it, scores a point and earns another turn,
+// revealing a safe cell passes the turn. The first player to claim the
+// majority of the mines wins.
+//
+// Claimed mines are flagged on the board, the Flags of the players are
+// their scores. The board itself is never lost.
+//
+// A duel is not safe for concurrent use, like its board.
+type Duel struct {
+	Board *Board
+
+	team *Team
+	turn int
+}
+
+// NewDuel creates a duel without players on the board.
+func NewDuel(board *Board) *Duel {
+	return &Duel{
+		Board: board,
+		team:  NewTeam(board),
+	}
+}
+
+// Join adds a player to the duel, see Team.Join. It fails once the duel has
+// two players.
+func (d *Duel) Join(name string) (*Player, error) {
+	if len(d.team.players) >= 2 {
+		return nil, ErrDuelFull
+	}
+
+	return d.team.Join(name), nil
+}
+
+// Players returns the players in the order they joined, the first player
+// starts.
+func (d *Duel) Players() []*Player {
+	return d.team.Players()
+}
+
+// Turn returns the player whose turn it is, nil until both players joined.
+func (d *Duel) Turn() *Player {
+	if len(d.team.players) < 2 {
+		return nil
+	}
+
+	return d.team.players[d.turn]
+}
+
+// Leave marks the player as gone, the other player wins.
+func (d *Duel) Leave(p *Player) {
+	d.team.Leave(p)
+}
+
+// Majority returns the number of mines a player needs to win.
+func (d *Duel) Majority() int {
+	return d.Board.NumMines/2 + 1
+}
+
+// Reveal plays a move of the player. It returns true if the cell was a
+// mine, the player keeps the turn then.
+func (d *Duel) Reveal(p *Player, row, col int) (mine bool, err error) {
+	if d.Over() {
+		return false, ErrGameOver
+	}
+
+	if d.Turn() != p {
+		return false, ErrNotYourTurn
+	}
+
+	if row < 0 || row >= d.Board.Rows || col < 0 || col >= d.Board.Cols {
+		return false, ErrOutOfBounds
+	}
+
+	cell := &d.Board.Cells[row][col]
+	if cell.IsRevealed || cell.IsFlagged {
+		return false, ErrAlreadyRevealed
+	}
+
+	if cell.IsMine {
+		// A claimed mine is flagged, the flag is the player's point
+		d.team.Play(p, func() error {
+			cell.IsFlagged = true
+			d.Board.emit(FlagChangedEvent{Row: row, Col: col, Flagged: true})
+			return nil
+		})
+
+		return true, nil
+	}
+
+	d.team.Play(p, func() error {
+		d.Board.Reveal(row, col)
+		return nil
+	})
+
+	d.turn = 1 - d.turn
+
+	return false, nil
+}
+
+// Over reports whether the duel is over: a player has the majority of the
+// mines or left, or all mines are claimed.
+func (d *Duel) Over() bool {
+	if len(d.team.players) < 2 {
+		return false
+	}
+
+	claimed := 0
+	for _, p := range d.team.players {
+		if p.Flags >= d.Majority() || p.Left {
+			return true
+		}
+
+		claimed += p.Flags
+	}
+
+	return claimed == d.Board.NumMines || d.Board.Status() != StatusPlaying
+}
+
+// Winner returns the winner once the duel is over, nil before and for a
+// draw.
+func (d *Duel) Winner() *Player {
+	if !d.Over() {
+		return nil
+	}
+
+	a, b := d.team.players[0], d.team.players[1]
+
+	switch {
+	case a.Left != b.Left:
+		if a.Left {
+			return b
+		}
+
+		return a
+	case a.Flags > b.Flags:
+		return a
+	case b.Flags > a.Flags:
+		return b
+	}
+
+	return nil
+}
+
+// Claims returns a cursor on every claimed mine in the color of the player
+// who claimed it.
+func (d *Duel) Claims() []Cursor {
+	var claims []Cursor
+	for pos, p := range d.team.owners {
+		if d.Board.Cells[pos.Row][pos.Col].IsMine {
+			claims = append(claims, Cursor{Position: pos, Color: p.Color})
+		}
+	}
+
+	return claims
+}
+
+// AIMove returns the cell the computer reveals: the hidden cell most likely
+// to be a mine, see MineProbabilities.
+func (d *Duel) AIMove() Position {
+	view := d.Board.PlayerView()
+	probabilities := MineProbabilities(view)
+
+	best, bestP := Position{Row: -1}, -1.0
+	for r, row := range probabilities {
+		for c, p := range row {
+			if view.Cells[r][c].State == CellHidden && p > bestP {
+				best, bestP = Position{Row: r, Col: c}, p
+			}
+		}
+	}
+
+	return best
+}
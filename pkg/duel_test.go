@@ -0,0 +1,96 @@
+package minesweeper_test
+
+import (
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestDuel(t *testing.T) {
+	board := minesweeper.NewBoard(5, 5, 3, &minesweeper.BoardOptions{Seed: 7}, nil)
+	duel := minesweeper.NewDuel(board)
+
+	alice, _ := duel.Join("alice")
+	bob, _ := duel.Join("bob")
+
+	if _, err := duel.Join("carol"); err != minesweeper.ErrDuelFull {
+		t.Errorf("expected the duel to be full, got %v", err)
+	}
+
+	var mines []minesweeper.Position
+	var safe minesweeper.Position
+	for r := 0; r < board.Rows; r++ {
+		for c := 0; c < board.Cols; c++ {
+			if board.Cells[r][c].IsMine {
+				mines = append(mines, minesweeper.Position{Row: r, Col: c})
+			} else if board.Cells[r][c].MinesAround > 0 {
+				safe = minesweeper.Position{Row: r, Col: c}
+			}
+		}
+	}
+
+	if _, err := duel.Reveal(bob, safe.Row, safe.Col); err != minesweeper.ErrNotYourTurn {
+		t.Errorf("expected alice to start, got %v", err)
+	}
+
+	// A mine is a point and another turn
+	if mine, err := duel.Reveal(alice, mines[0].Row, mines[0].Col); !mine || err != nil || duel.Turn() != alice || alice.Flags != 1 {
+		t.Errorf("expected alice to claim a mine and keep the turn, got %v %v", mine, err)
+	}
+
+	if board.Status() != minesweeper.StatusPlaying {
+		t.Errorf("expected a claimed mine not to lose the board, got %v", board.Status())
+	}
+
+	if _, err := duel.Reveal(alice, mines[0].Row, mines[0].Col); err != minesweeper.ErrAlreadyRevealed {
+		t.Errorf("expected a claimed mine to be taken, got %v", err)
+	}
+
+	// A safe cell passes the turn
+	if mine, err := duel.Reveal(alice, safe.Row, safe.Col); mine || err != nil || duel.Turn() != bob {
+		t.Errorf("expected the turn to pass to bob, got %v %v", mine, err)
+	}
+
+	duel.Reveal(bob, mines[1].Row, mines[1].Col)
+	if duel.Over() {
+		t.Fatal("expected the duel to go on at 1 to 1")
+	}
+
+	duel.Reveal(bob, mines[2].Row, mines[2].Col)
+	if !duel.Over() || duel.Winner() != bob || duel.Majority() != 2 {
+		t.Errorf("expected bob to win with the majority, got %+v", duel.Winner())
+	}
+
+	if len(duel.Claims()) != 3 {
+		t.Errorf("expected 3 claimed mines, got %v", duel.Claims())
+	}
+}
+
+func TestDuelAIMove(t *testing.T) {
+	board := minesweeper.NewBoard(5, 5, 3, &minesweeper.BoardOptions{Seed: 7}, nil)
+	duel := minesweeper.NewDuel(board)
+
+	ai, _ := duel.Join("computer")
+	duel.Join("alice")
+
+	// The computer finds every mine the numbers give away
+	for r := 0; r < board.Rows; r++ {
+		for c := 0; c < board.Cols; c++ {
+			if board.Cells[r][c].MinesAround == 0 && !board.Cells[r][c].IsMine {
+				board.Reveal(r, c)
+			}
+		}
+	}
+
+	// Every mine is given away, the computer claims them all in one turn
+	for !duel.Over() {
+		pos := duel.AIMove()
+		if mine, err := duel.Reveal(ai, pos.Row, pos.Col); !mine || err != nil {
+			t.Fatalf("expected %v to be a mine, got %v %v", pos, mine, err)
+		}
+	}
+
+	if duel.Winner() != ai || ai.Flags != 2 {
+		t.Errorf("expected the computer to win with 2 mines, got %+v", duel.Winner())
+	}
+}
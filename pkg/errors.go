@@ -0,0 +1,21 @@
+package minesweeper
+
+import "errors"
+
+// Errors returned for moves that can't be made
+var (
+	ErrOutOfBounds     = errors.New("cell is outside the board")
+	ErrAlreadyRevealed = errors.New("cell is already revealed")
+	ErrCellFlagged     = errors.New("cell is flagged")
+	ErrGameOver        = errors.New("game is over")
+	ErrNotYourTurn     = errors.New("it is not your turn")
+	ErrDuelFull        = errors.New("duel has two players already")
+)
+
+// ErrRaceVersion is returned when a race was set up with a first-click rule
+// or generator version the boards of its racers can't be generated with.
+var ErrRaceVersion = errors.New("race boards are generated with another first-click rule or version")
+
+// ErrDifficultyRange is returned when no board in the difficulty range of
+// the BoardOptions was found.
+var ErrDifficultyRange = errors.New("no board in the difficulty range was found")
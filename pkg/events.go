@@ -0,0 +1,85 @@
+package minesweeper
+
+// Position is the position of a cell on the board.
+type Position struct {
+	Row int
+	Col int
+}
+
+// Event is emitted by a board when its state changes. It is one of
+// GameStartedEvent, CellsRevealedEvent, FlagChangedEvent, MineHitEvent,
+// GameWonEvent or BoardRevealedEvent.
+type Event interface {
+	event()
+}
+
+// GameStartedEvent is emitted on the first reveal of a board.
+type GameStartedEvent struct {
+	Row int
+	Col int
+}
+
+// CellsRevealedEvent is emitted when cells are revealed. A single reveal can
+// reveal a whole opening, all cells revealed by it are sent in one batch. A
+// chord is one batch too.
+type CellsRevealedEvent struct {
+	Cells []Position
+}
+
+// FlagChangedEvent is emitted when a flag is placed or removed.
+type FlagChangedEvent struct {
+	Row     int
+	Col     int
+	Flagged bool
+}
+
+// MineHitEvent is emitted when a mine is revealed.
+type MineHitEvent struct {
+	Row int
+	Col int
+}
+
+// GameWonEvent is emitted when the last safe cell is revealed.
+type GameWonEvent struct{}
+
+// BoardRevealedEvent is emitted when the whole board is revealed with
+// RevealAll. It ends the game without a win.
+type BoardRevealedEvent struct{}
+
+func (GameStartedEvent) event()   {}
+func (CellsRevealedEvent) event() {}
+func (FlagChangedEvent) event()   {}
+func (MineHitEvent) event()       {}
+func (GameWonEvent) event()       {}
+func (BoardRevealedEvent) event() {}
+
+// Listener is called with every event of the board it is subscribed to.
+// Listeners are called synchronously in the order they subscribed.
+type Listener func(event Event)
+
+type subscription struct {
+	listener Listener
+}
+
+// Subscribe registers a listener for the events of the board. The returned
+// function removes the listener again.
+func (b *Board) Subscribe(listener Listener) (unsubscribe func()) {
+	sub := &subscription{listener: listener}
+	b.subscriptions = append(b.subscriptions, sub)
+
+	return func() {
+		for i, s := range b.subscriptions {
+			if s == sub {
+				b.subscriptions = append(b.subscriptions[:i:i], b.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emit sends the event to all listeners of the board.
+func (b *Board) emit(event Event) {
+	for _, sub := range b.subscriptions {
+		sub.listener(event)
+	}
+}
@@ -0,0 +1,141 @@
+package minesweeper_test
+
+import (
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestSubscribe(t *testing.T) {
+	rows, cols, numMines := 10, 10, 10
+	boardOptions := &minesweeper.BoardOptions{Seed: 5}
+	displayOptions := &minesweeper.DisplayOptions{StartIndex: nil, ANSI: nil}
+
+	board := minesweeper.NewBoard(rows, cols, numMines, boardOptions, displayOptions)
+
+	var events []minesweeper.Event
+	unsubscribe := board.Subscribe(func(event minesweeper.Event) {
+		events = append(events, event)
+	})
+
+	board.Reveal(0, 0)
+
+	if len(events) != 2 {
+		t.Fatalf("Expected %d events, but got %d", 2, len(events))
+	}
+
+	if _, ok := events[0].(minesweeper.GameStartedEvent); !ok {
+		t.Errorf("Expected first event to be GameStartedEvent, but got %T", events[0])
+	}
+
+	revealed, ok := events[1].(minesweeper.CellsRevealedEvent)
+	if !ok {
+		t.Fatalf("Expected second event to be CellsRevealedEvent, but got %T", events[1])
+	}
+
+	if len(revealed.Cells) != board.CellsRevealed() {
+		t.Errorf("Expected %d revealed cells in the event, but got %d", board.CellsRevealed(), len(revealed.Cells))
+	}
+
+	board.ToggleFlag(9, 9)
+
+	flag, ok := events[2].(minesweeper.FlagChangedEvent)
+	if !ok || !flag.Flagged || flag.Row != 9 || flag.Col != 9 {
+		t.Errorf("Expected a flag placed at (9, 9), but got %+v", events[2])
+	}
+
+	// Reveal cell with a mine
+	board.Reveal(9, 0)
+
+	if _, ok := events[len(events)-1].(minesweeper.MineHitEvent); !ok {
+		t.Errorf("Expected last event to be MineHitEvent, but got %T", events[len(events)-1])
+	}
+
+	unsubscribe()
+
+	count := len(events)
+	board.ToggleFlag(9, 9)
+
+	if len(events) != count {
+		t.Errorf("Expected no events after unsubscribing, but got %d", len(events)-count)
+	}
+}
+
+func TestGameWonEvent(t *testing.T) {
+	rows, cols, numMines := 10, 10, 10
+	boardOptions := &minesweeper.BoardOptions{Seed: 5}
+	displayOptions := &minesweeper.DisplayOptions{StartIndex: nil, ANSI: nil}
+
+	board := minesweeper.NewBoard(rows, cols, numMines, boardOptions, displayOptions)
+
+	won := false
+	board.Subscribe(func(event minesweeper.Event) {
+		if _, ok := event.(minesweeper.GameWonEvent); ok {
+			won = true
+		}
+	})
+
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if !board.Cells[r][c].IsMine {
+				board.Reveal(r, c)
+			}
+		}
+	}
+
+	if !won {
+		t.Error("Expected GameWonEvent after revealing all safe cells")
+	}
+}
+
+func TestChordEvents(t *testing.T) {
+	// A mine in the corner, flagged next to the revealed center
+	board := minesweeper.NewBoard(3, 3, 0, &minesweeper.BoardOptions{Seed: 5}, nil)
+	board.NumMines = 1
+	board.Cells[0][0].IsMine = true
+	board.Cells[0][1].MinesAround = 1
+	board.Cells[1][0].MinesAround = 1
+	board.Cells[1][1].MinesAround = 1
+
+	board.Reveal(1, 1)
+	board.ToggleFlag(0, 0)
+
+	var events []minesweeper.Event
+	board.Subscribe(func(event minesweeper.Event) {
+		events = append(events, event)
+	})
+
+	board.Chord(1, 1)
+
+	if len(events) != 2 {
+		t.Fatalf("Expected one batch of cells and a win, but got %#v", events)
+	}
+
+	if revealed, ok := events[0].(minesweeper.CellsRevealedEvent); !ok || len(revealed.Cells) != 7 {
+		t.Errorf("Expected the 7 other neighbours in one batch, but got %#v", events[0])
+	}
+
+	if _, ok := events[1].(minesweeper.GameWonEvent); !ok {
+		t.Errorf("Expected GameWonEvent, but got %#v", events[1])
+	}
+}
+
+func TestRevealAllEvents(t *testing.T) {
+	board := minesweeper.NewBoard(5, 5, 3, &minesweeper.BoardOptions{Seed: 5}, nil)
+
+	won, revealed := false, false
+	board.Subscribe(func(event minesweeper.Event) {
+		switch event.(type) {
+		case minesweeper.GameWonEvent:
+			won = true
+		case minesweeper.BoardRevealedEvent:
+			revealed = true
+		}
+	})
+
+	board.RevealAll()
+
+	if won || !revealed {
+		t.Errorf("Expected BoardRevealedEvent without GameWonEvent, but got won %v, revealed %v", won, revealed)
+	}
+}
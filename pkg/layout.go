@@ -0,0 +1,199 @@
+package minesweeper
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Layout holds the widths used to align the rendered board. All widths are
+// in terminal columns.
+type Layout struct {
+	// Gutter is the width in front of the cells, the left index and "| "
+	Gutter int
+	// RowIndexWidth is the width of the row indices
+	RowIndexWidth int
+	// ColIndexWidth is the width of the column indices
+	ColIndexWidth int
+	// CellWidth is the width every cell is padded to
+	CellWidth int
+	// SeparatorWidth is the width of the separator between cells
+	SeparatorWidth int
+}
+
+// Pitch is the distance from the start of one cell to the start of the next.
+func (l Layout) Pitch() int {
+	return l.CellWidth + l.SeparatorWidth
+}
+
+// Minimum width of indices, small boards keep the classic two column indices
+const minIndexWidth = 2
+
+// Layout measures the symbols and indices of the board and returns the
+// widths that align them. Indices are right aligned with the right edge of
+// their cell and may stretch into the separator, cells are made wider if
+// the indices don't fit.
+func (b *Board) Layout() Layout {
+	options := b.DisplayOptions
+	view := b.view()
+
+	l := Layout{
+		RowIndexWidth:  maxInt(minIndexWidth, len(b.RowLabel(view.Row+view.Rows-1))),
+		ColIndexWidth:  maxInt(minIndexWidth, len(b.ColLabel(view.Col+view.Cols-1))),
+		CellWidth:      1,
+		SeparatorWidth: DisplayWidth(*options.SymbolSeperator),
+	}
+
+	for _, symbol := range []string{*options.SymbolMine, *options.SymbolFlag, *options.SymbolHidden} {
+		l.CellWidth = maxInt(l.CellWidth, DisplayWidth(symbol))
+	}
+
+	// Keep at least one space between column indices
+	if *options.TopIndex || *options.BottomIndex {
+		l.CellWidth = maxInt(l.CellWidth, l.ColIndexWidth+1-l.SeparatorWidth)
+	}
+
+	if *options.LeftIndex {
+		l.Gutter = l.RowIndexWidth + 2
+	}
+
+	// Make room for the column index of the first column
+	if *options.TopIndex || *options.BottomIndex {
+		l.Gutter = maxInt(l.Gutter, l.ColIndexWidth-l.CellWidth)
+	}
+
+	return l
+}
+
+// padLeft pads s with spaces on the left to the given display width.
+func padLeft(s string, width int) string {
+	if n := width - DisplayWidth(s); n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+
+	return s
+}
+
+// padRight pads s with spaces on the right to the given display width.
+func padRight(s string, width int) string {
+	if n := width - DisplayWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+
+	return s
+}
+
+// DisplayWidth returns the number of terminal columns s takes up. Wide East
+// Asian characters and emoji take two columns, combining marks and other
+// zero width characters none. ANSI escape codes are ignored.
+func DisplayWidth(s string) int {
+	s = RemoveAnsiEscapeCodes(s)
+
+	width := 0
+	runes := []rune(s)
+
+	for i, r := range runes {
+		w := runeWidth(r)
+
+		// An emoji presentation selector makes the character before it wide
+		if r == 0xfe0f && i > 0 && runeWidth(runes[i-1]) == 1 {
+			w = 1
+		}
+
+		width += w
+	}
+
+	return width
+}
+
+// runeWidth returns the number of terminal columns of a single rune.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0:
+		return 0
+	case r < 0x20 || (r >= 0x7f && r < 0xa0):
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0xfe00 && r <= 0xfe0f, r >= 0xe0100 && r <= 0xe01ef:
+		// Variation selectors
+		return 0
+	}
+
+	for _, wide := range wideRanges {
+		if r < wide[0] {
+			break
+		}
+
+		if r <= wide[1] {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+// Ranges of wide characters: East Asian wide and fullwidth characters and
+// emoji shown as pictures by default. Sorted by their first rune.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x2329, 0x232a},
+	{0x23e9, 0x23ec},
+	{0x23f0, 0x23f0},
+	{0x23f3, 0x23f3},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267f, 0x267f},
+	{0x2693, 0x2693},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26ce, 0x26ce},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f3},
+	{0x26f5, 0x26f5},
+	{0x26fa, 0x26fa},
+	{0x26fd, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x274e, 0x274e},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27b0, 0x27b0},
+	{0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2b55, 0x2b55},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xa960, 0xa97f},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x16fe0, 0x16fe4},
+	{0x17000, 0x18cff},
+	{0x1b000, 0x1b2ff},
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a},
+	{0x1f200, 0x1f251},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f90c, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+	{0x20000, 0x3fffd},
+}
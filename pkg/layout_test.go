@@ -0,0 +1,81 @@
+package minesweeper_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/TechMDW/minesweeper/internal/util"
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"12", 2},
+		{"💣", 2},
+		{"旗", 2},
+		{"é", 1},
+		{"⚑️", 2},
+		{"\x1b[31m*\x1b[0m", 1},
+	}
+
+	for _, test := range tests {
+		if width := minesweeper.DisplayWidth(test.text); width != test.width {
+			t.Errorf("Expected width of %q to be %d, but got %d", test.text, test.width, width)
+		}
+	}
+}
+
+func TestLayoutAlignment(t *testing.T) {
+	displayOptions := &minesweeper.DisplayOptions{
+		SymbolHidden: util.StringPtr("🟩"),
+		RightIndex:   util.BoolPtr(true),
+		BottomIndex:  util.BoolPtr(true),
+		StartIndex:   util.IntPtr(98),
+	}
+
+	board := minesweeper.NewBoard(4, 4, 2, &minesweeper.BoardOptions{Seed: 1}, displayOptions)
+
+	layout := board.Layout()
+	if layout.CellWidth != 2 || layout.RowIndexWidth != 3 || layout.Gutter != 5 {
+		t.Errorf("Expected cell width 2, row index width 3 and gutter 5, but got %+v", layout)
+	}
+
+	var buf bytes.Buffer
+	(minesweeper.PlainRenderer{}).Render(&buf, board.PlayerView(), board.DisplayOptions)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	// The right index starts at the same column in every row and the last
+	// column index ends with its cell
+	for _, line := range lines[1 : len(lines)-1] {
+		cells := line[:strings.LastIndex(line, " |")]
+		if w := minesweeper.DisplayWidth(cells); w != layout.Gutter+4*layout.Pitch()-layout.SeparatorWidth {
+			t.Errorf("Expected the right index to be aligned, but got %q", line)
+		}
+	}
+
+	for _, line := range []string{lines[0], lines[len(lines)-1]} {
+		if !strings.HasSuffix(strings.TrimRight(line, " "), "101") {
+			t.Errorf("Expected column indices to end with 101, but got %q", line)
+		}
+
+		if end := minesweeper.DisplayWidth(strings.TrimRight(line, " ")); end != layout.Gutter+4*layout.Pitch()-layout.SeparatorWidth {
+			t.Errorf("Expected column indices to end at the last cell, but got %q", line)
+		}
+	}
+
+	if !strings.HasPrefix(lines[1], " 98| ") {
+		t.Errorf("Expected row index to be right aligned, but got %q", lines[1])
+	}
+
+	pos, ok := board.CellAt(layout.Gutter+layout.Pitch()+1, 2)
+	if !ok || pos.Row != 1 || pos.Col != 1 {
+		t.Errorf("Expected cell (1, 1), but got (%d, %d)", pos.Row, pos.Col)
+	}
+}
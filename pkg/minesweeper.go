@@ -1,9 +1,11 @@
 package minesweeper
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"math/rand"
-	"reflect"
+	"os"
 	"time"
 
 	"github.com/TechMDW/minesweeper/internal/util"
@@ -27,10 +29,43 @@ type Board struct {
 
 	BoardOptions   *BoardOptions
 	DisplayOptions *DisplayOptions
+
+	started       bool
+	subscriptions []*subscription
+}
+
+// GeneratorVersion is the version of the board generation, the PRNG and the
+// way mines are placed from the seed. Boards with the same size, mines, seed,
+// first-click rule and version are identical. It changes whenever a seed
+// would give other boards.
+const GeneratorVersion = 1
+
+// FirstClickRule is what the first reveal does to the mines.
+type FirstClickRule int
+
+const (
+	// FirstClickAny keeps the mines where they were placed, the first reveal
+	// can hit one.
+	FirstClickAny FirstClickRule = iota
+)
+
+func (r FirstClickRule) String() string {
+	switch r {
+	case FirstClickAny:
+		return "any"
+	}
+
+	return "unknown"
 }
 
 type BoardOptions struct {
 	Seed int64
+
+	// Range of the difficulty score (see Rate) the generated board should
+	// have. A value of 0 means no limit. Mines are placed again until the
+	// board is in range, see NewBoardContext.
+	MinDifficulty float64
+	MaxDifficulty float64
 }
 
 type DisplayOptions struct {
@@ -46,6 +81,40 @@ type DisplayOptions struct {
 	SymbolFlag      *string
 	SymbolHidden    *string
 	SymbolSeperator *string
+
+	// Scheme used to label rows and columns, numeric if nil
+	Coordinates *Coordinates
+
+	// Colors used by the ANSI renderer
+	Theme      *Theme
+	ColorDepth *ColorDepth
+
+	// Part of the board that is rendered, nil renders the whole board
+	Viewport *Viewport
+	// Show a minimap of the board next to the viewport
+	Minimap *bool
+}
+
+// GameStatus is the status of the game played on a board.
+type GameStatus int
+
+const (
+	StatusPlaying GameStatus = iota
+	StatusWon
+	StatusLost
+)
+
+func (s GameStatus) String() string {
+	switch s {
+	case StatusPlaying:
+		return "playing"
+	case StatusWon:
+		return "won"
+	case StatusLost:
+		return "lost"
+	}
+
+	return "unknown"
 }
 
 // Symbols used to display the board
@@ -60,10 +129,39 @@ const (
 	dStartIndex = 1
 )
 
+// MaxRatingAttempts is the number of boards generated to find one in the
+// difficulty range of the BoardOptions.
+const MaxRatingAttempts = 1000
+
+// MaxRatingTime is the time spent generating boards to find one in the
+// difficulty range of the BoardOptions.
+const MaxRatingTime = 2 * time.Second
+
 // NewBoard creates a new board with the given number of rows, columns, and mines.
 //
-// The board is initialized with all cells hidden and no mines placed.
+// The board is initialized with all cells hidden and no mines placed. A
+// board outside the difficulty range of the BoardOptions is returned if none
+// in range was found, NewBoardContext reports that.
 func NewBoard(rows, cols, numMines int, boardOptions *BoardOptions, displayOptions *DisplayOptions) *Board {
+	board, _ := NewBoardContext(context.Background(), rows, cols, numMines, boardOptions, displayOptions)
+	return board
+}
+
+// NewBoardContext creates a new board like NewBoard. Boards are generated
+// until one is in the difficulty range of the BoardOptions, for at most
+// MaxRatingAttempts boards and MaxRatingTime, or until ctx is done. If no
+// board was in range, the last one is returned with ErrDifficultyRange.
+func NewBoardContext(ctx context.Context, rows, cols, numMines int, boardOptions *BoardOptions, displayOptions *DisplayOptions) (*Board, error) {
+	// More mines than cells would never finish placing them, a board needs
+	// a safe cell to be played. Use ValidateBoard to report invalid boards.
+	if numMines > rows*cols-1 {
+		numMines = rows*cols - 1
+	}
+
+	if numMines < 0 {
+		numMines = 0
+	}
+
 	board := &Board{
 		Rows:     rows,
 		Cols:     cols,
@@ -80,80 +178,153 @@ func NewBoard(rows, cols, numMines int, boardOptions *BoardOptions, displayOptio
 		board.DisplayOptions = &DisplayOptions{}
 	}
 
-	if board.DisplayOptions.StartIndex == nil {
-		board.DisplayOptions.StartIndex = util.IntPtr(1)
+	board.DisplayOptions.setDefaults()
+
+	// Check board options are nil and set them to their default values
+	if board.BoardOptions == nil {
+		board.BoardOptions = &BoardOptions{}
 	}
 
-	if board.DisplayOptions.ANSI == nil {
-		board.DisplayOptions.ANSI = util.BoolPtr(true)
+	if board.BoardOptions.Seed == 0 {
+		board.BoardOptions.Seed = time.Now().UnixNano()
 	}
 
-	if board.DisplayOptions.LeftIndex == nil {
-		board.DisplayOptions.LeftIndex = util.BoolPtr(true)
+	board.placeMines()
+
+	if board.BoardOptions.MinDifficulty <= 0 && board.BoardOptions.MaxDifficulty <= 0 {
+		return board, nil
 	}
 
-	if board.DisplayOptions.RightIndex == nil {
-		board.DisplayOptions.RightIndex = util.BoolPtr(false)
+	ctx, cancel := context.WithTimeout(ctx, MaxRatingTime)
+	defer cancel()
+
+	for attempt := 1; !board.inDifficultyRange(); attempt++ {
+		if attempt >= MaxRatingAttempts {
+			return board, ErrDifficultyRange
+		}
+
+		if err := ctx.Err(); err != nil {
+			return board, fmt.Errorf("%w: %w", ErrDifficultyRange, err)
+		}
+
+		board.placeMines()
 	}
 
-	if board.DisplayOptions.SymbolMine == nil {
-		board.DisplayOptions.SymbolMine = util.StringPtr(SymbolMine)
+	return board, nil
+}
+
+// placeMines clears the board and places mines randomly on it.
+func (b *Board) placeMines() {
+	for i := 0; i < b.Rows; i++ {
+		b.Cells[i] = make([]Cell, b.Cols)
 	}
 
-	if board.DisplayOptions.SymbolFlag == nil {
-		board.DisplayOptions.SymbolFlag = util.StringPtr(SymbolFlag)
+	for i := 0; i < b.NumMines; i++ {
+		for {
+			row := b.Rand.Intn(b.Rows)
+			col := b.Rand.Intn(b.Cols)
+
+			if !b.Cells[row][col].IsMine {
+				b.Cells[row][col].IsMine = true
+
+				b.incrementMinesAround(row, col)
+
+				break
+			}
+		}
 	}
+}
 
-	if board.DisplayOptions.SymbolHidden == nil {
-		board.DisplayOptions.SymbolHidden = util.StringPtr(SymbolHidden)
+// setDefaults sets the options that are nil to their default values.
+func (o *DisplayOptions) setDefaults() {
+	if o.StartIndex == nil {
+		o.StartIndex = util.IntPtr(1)
 	}
 
-	if board.DisplayOptions.SymbolSeperator == nil {
-		board.DisplayOptions.SymbolSeperator = util.StringPtr(SymbolSeperator)
+	if o.ANSI == nil {
+		o.ANSI = util.BoolPtr(true)
 	}
 
-	if board.DisplayOptions.TopIndex == nil {
-		board.DisplayOptions.TopIndex = util.BoolPtr(true)
+	if o.LeftIndex == nil {
+		o.LeftIndex = util.BoolPtr(true)
 	}
 
-	if board.DisplayOptions.BottomIndex == nil {
-		board.DisplayOptions.BottomIndex = util.BoolPtr(false)
+	if o.RightIndex == nil {
+		o.RightIndex = util.BoolPtr(false)
 	}
 
-	// Check board options are nil and set them to their default values
-	if board.BoardOptions == nil {
-		board.BoardOptions = &BoardOptions{}
+	if o.SymbolMine == nil {
+		o.SymbolMine = util.StringPtr(SymbolMine)
 	}
 
-	if board.BoardOptions.Seed == 0 {
-		board.BoardOptions.Seed = time.Now().UnixNano()
+	if o.SymbolFlag == nil {
+		o.SymbolFlag = util.StringPtr(SymbolFlag)
 	}
 
-	for i := 0; i < rows; i++ {
-		board.Cells[i] = make([]Cell, cols)
+	if o.SymbolHidden == nil {
+		o.SymbolHidden = util.StringPtr(SymbolHidden)
 	}
 
-	board.placeMines()
+	if o.SymbolSeperator == nil {
+		o.SymbolSeperator = util.StringPtr(SymbolSeperator)
+	}
 
-	return board
+	if o.Coordinates == nil {
+		coordinates := CoordinatesNumeric
+		o.Coordinates = &coordinates
+	}
+
+	if o.Theme == nil {
+		theme := ThemeClassic
+		o.Theme = &theme
+	}
+
+	if o.ColorDepth == nil {
+		depth := DetectColorDepth()
+		o.ColorDepth = &depth
+	}
+
+	if o.Minimap == nil {
+		o.Minimap = util.BoolPtr(false)
+	}
+
+	if o.TopIndex == nil {
+		o.TopIndex = util.BoolPtr(true)
+	}
+
+	if o.BottomIndex == nil {
+		o.BottomIndex = util.BoolPtr(false)
+	}
 }
 
-// placeMines places mines randomly on the board.
-func (b *Board) placeMines() {
-	for i := 0; i < b.NumMines; i++ {
-		for {
-			row := b.Rand.Intn(b.Rows)
-			col := b.Rand.Intn(b.Cols)
+// withDefaults returns a copy of the options with the options that are nil
+// set to their default values, like NewBoard does. nil options are all
+// defaults.
+func (o *DisplayOptions) withDefaults() *DisplayOptions {
+	options := &DisplayOptions{}
+	if o != nil {
+		*options = *o
+	}
 
-			if !b.Cells[row][col].IsMine {
-				b.Cells[row][col].IsMine = true
+	options.setDefaults()
 
-				b.incrementMinesAround(row, col)
+	return options
+}
 
-				break
-			}
-		}
+// inDifficultyRange reports whether the rating of the board is in the range
+// of the BoardOptions.
+func (b *Board) inDifficultyRange() bool {
+	score := Rate(b).Score
+
+	if b.BoardOptions.MinDifficulty > 0 && score < b.BoardOptions.MinDifficulty {
+		return false
+	}
+
+	if b.BoardOptions.MaxDifficulty > 0 && score > b.BoardOptions.MaxDifficulty {
+		return false
 	}
+
+	return true
 }
 
 // incrementMinesAround increments the MinesAround field of all cells in the
@@ -170,6 +341,26 @@ func (b *Board) incrementMinesAround(row, col int) {
 	}
 }
 
+// forEachCell calls fn for the cell at row, col and all of its neighbours on the board.
+func (b *Board) forEachCell(row, col int, fn func(r, c int)) {
+	for r := row - 1; r <= row+1; r++ {
+		for c := col - 1; c <= col+1; c++ {
+			if r >= 0 && r < b.Rows && c >= 0 && c < b.Cols {
+				fn(r, c)
+			}
+		}
+	}
+}
+
+// forEachNeighbour calls fn for all neighbours of the cell at row, col on the board.
+func (b *Board) forEachNeighbour(row, col int, fn func(r, c int)) {
+	b.forEachCell(row, col, func(r, c int) {
+		if r != row || c != col {
+			fn(r, c)
+		}
+	})
+}
+
 // Reveal recursively reveals all the cells around a cell. If a mine is revealed, it returns true.
 // Otherwise, it returns false.
 func (b *Board) Reveal(row, col int) bool {
@@ -177,7 +368,44 @@ func (b *Board) Reveal(row, col int) bool {
 		return false
 	}
 
+	if !b.started {
+		b.started = true
+		b.emit(GameStartedEvent{Row: row, Col: col})
+	}
+
+	var revealed []Position
+	hitMine := b.reveal(row, col, &revealed)
+
+	hit := Position{Row: row, Col: col}
+	b.emitRevealed(revealed, hitMine, hit)
+
+	return hitMine
+}
+
+// emitRevealed emits the events of one move that revealed the cells: the
+// cells, then a mine hit at hit or a win.
+func (b *Board) emitRevealed(revealed []Position, hitMine bool, hit Position) {
+	if len(revealed) == 0 {
+		return
+	}
+
+	b.emit(CellsRevealedEvent{Cells: revealed})
+
+	if hitMine {
+		b.emit(MineHitEvent{Row: hit.Row, Col: hit.Col})
+	} else if b.RevealedPercentage() == 1 {
+		b.emit(GameWonEvent{})
+	}
+}
+
+// reveal recursively reveals all the cells around a cell and adds them to revealed.
+func (b *Board) reveal(row, col int, revealed *[]Position) bool {
+	if row < 0 || row >= b.Rows || col < 0 || col >= b.Cols || b.Cells[row][col].IsRevealed {
+		return false
+	}
+
 	b.Cells[row][col].IsRevealed = true
+	*revealed = append(*revealed, Position{Row: row, Col: col})
 
 	if b.Cells[row][col].IsMine {
 		return true
@@ -186,7 +414,7 @@ func (b *Board) Reveal(row, col int) bool {
 	if b.Cells[row][col].MinesAround == 0 {
 		for r := row - 1; r <= row+1; r++ {
 			for c := col - 1; c <= col+1; c++ {
-				b.Reveal(r, c)
+				b.reveal(r, c, revealed)
 			}
 		}
 	}
@@ -194,6 +422,42 @@ func (b *Board) Reveal(row, col int) bool {
 	return false
 }
 
+// Chord reveals all hidden, unflagged neighbours of a revealed cell if the
+// number of flags around it equals its number. If a mine is revealed, it
+// returns true. Otherwise, it returns false.
+func (b *Board) Chord(row, col int) bool {
+	if row < 0 || row >= b.Rows || col < 0 || col >= b.Cols || !b.Cells[row][col].IsRevealed {
+		return false
+	}
+
+	flags := 0
+	b.forEachNeighbour(row, col, func(r, c int) {
+		if b.Cells[r][c].IsFlagged {
+			flags++
+		}
+	})
+
+	if flags != b.Cells[row][col].MinesAround {
+		return false
+	}
+
+	// The cells of all neighbours are one move with one batch of events
+	var revealed []Position
+	var hit Position
+	hitMine := false
+
+	b.forEachNeighbour(row, col, func(r, c int) {
+		if !b.Cells[r][c].IsFlagged && b.reveal(r, c, &revealed) && !hitMine {
+			hitMine = true
+			hit = Position{Row: r, Col: c}
+		}
+	})
+
+	b.emitRevealed(revealed, hitMine, hit)
+
+	return hitMine
+}
+
 func (b *Board) IsFlagged(row, col int) bool {
 	if row < 0 || row >= b.Rows || col < 0 || col >= b.Cols || b.Cells[row][col].IsRevealed {
 		return false
@@ -245,18 +509,33 @@ func (b *Board) FlagsCount() int {
 }
 
 func (b *Board) RevealAll() {
+	var revealed []Position
+
 	for i := 0; i < b.Rows; i++ {
 		for j := 0; j < b.Cols; j++ {
 			cell := &b.Cells[i][j]
 
 			if cell.IsMine {
-				cell.IsFlagged = true
+				if !cell.IsFlagged {
+					cell.IsFlagged = true
+					b.emit(FlagChangedEvent{Row: i, Col: j, Flagged: true})
+				}
+
 				continue
 			}
 
-			cell.IsRevealed = true
+			if !cell.IsRevealed {
+				cell.IsRevealed = true
+				revealed = append(revealed, Position{Row: i, Col: j})
+			}
 		}
 	}
+
+	// Revealing the board is not a win
+	if len(revealed) > 0 {
+		b.emit(CellsRevealedEvent{Cells: revealed})
+		b.emit(BoardRevealedEvent{})
+	}
 }
 
 func (b *Board) RevealedPercentage() float64 {
@@ -275,152 +554,105 @@ func (b *Board) RevealedPercentage() float64 {
 		}
 	}
 
+	// Nothing to clear on a board without safe cells
+	if nonMineCells == 0 {
+		return 1
+	}
+
 	percentage := float64(revealedNonMineCells) / float64(nonMineCells)
 
 	return percentage
 }
 
-func (b *Board) ToggleFlag(row, col int) {
-	if row >= 0 && row < b.Rows && col >= 0 && col < b.Cols {
-		b.Cells[row][col].IsFlagged = !b.Cells[row][col].IsFlagged
-	}
-}
-
-func (b *Board) Display(showMines bool) {
-	// Add padding to the left for the column numbers
-	fmt.Print("   ")
-
-	startIndex := dStartIndex
-	if b.DisplayOptions.StartIndex != nil {
-		startIndex = *b.DisplayOptions.StartIndex
-	}
+// Status returns whether the game is still being played, won or lost. The
+// game is lost once a mine is revealed and won once all safe cells are.
+func (b *Board) Status() GameStatus {
+	safeLeft := false
 
-	symbolMine := *b.DisplayOptions.SymbolMine
-	symbolFlag := *b.DisplayOptions.SymbolFlag
-	symbolHidden := *b.DisplayOptions.SymbolHidden
-	symbolSeperator := *b.DisplayOptions.SymbolSeperator
+	for _, row := range b.Cells {
+		for _, cell := range row {
+			if cell.IsMine && cell.IsRevealed {
+				return StatusLost
+			}
 
-	if *b.DisplayOptions.TopIndex {
-		for c := 0; c < b.Cols; c++ {
-			b.Printf("\x1b[34m%2d\x1b[0m ", c+startIndex)
+			if !cell.IsMine && !cell.IsRevealed {
+				safeLeft = true
+			}
 		}
 	}
 
-	// Print new line after the top row
-	fmt.Println()
-
-	for r := 0; r < b.Rows; r++ {
-		if *b.DisplayOptions.LeftIndex {
-			b.Printf("\x1b[34m%2d\x1b[0m| ", r+startIndex)
-		}
-
-		for c := 0; c < b.Cols; c++ {
-			cell := b.Cells[r][c]
-
-			seperator := symbolSeperator
+	if safeLeft {
+		return StatusPlaying
+	}
 
-			if c == b.Cols-1 {
-				seperator = ""
-			}
+	return StatusWon
+}
 
-			if cell.IsRevealed {
-				if cell.IsMine {
-					b.Printf("\x1b[41m%s\x1b[0m%s", symbolMine, seperator)
-				} else {
-					switch cell.MinesAround {
-					case 1:
-						b.Printf("\x1b[94m%d\x1b[0m%s", cell.MinesAround, seperator)
-					case 2:
-						b.Printf("\x1b[32m%d\x1b[0m%s", cell.MinesAround, seperator)
-					case 3:
-						b.Printf("\x1b[31m%d\x1b[0m%s", cell.MinesAround, seperator)
-					case 4:
-						b.Printf("\x1b[34m%d\x1b[0m%s", cell.MinesAround, seperator)
-					case 5:
-						b.Printf("\x1b[33m%d\x1b[0m%s", cell.MinesAround, seperator)
-					case 6:
-						b.Printf("\x1b[36m%d\x1b[0m%s", cell.MinesAround, seperator)
-					case 7:
-						b.Printf("\x1b[30m%d\x1b[0m%s", cell.MinesAround, seperator)
-					default:
-						b.Printf("\x1b[90m%d\x1b[0m%s", cell.MinesAround, seperator)
-					}
-				}
-			} else {
-				if showMines && cell.IsMine {
-					b.Printf("\x1b[41m%s\x1b[0m%s", symbolMine, seperator)
-				} else if cell.IsFlagged {
-					b.Printf("\x1b[91m%s\x1b[0m%s", symbolFlag, seperator)
-				} else {
-					b.Printf("\x1b[37m%s\x1b[0m%s", symbolHidden, seperator)
-				}
-			}
-		}
+func (b *Board) ToggleFlag(row, col int) {
+	if row >= 0 && row < b.Rows && col >= 0 && col < b.Cols {
+		b.Cells[row][col].IsFlagged = !b.Cells[row][col].IsFlagged
 
-		if *b.DisplayOptions.RightIndex {
-			b.Printf(" |\x1b[34m%d\x1b[0m", r+startIndex)
-		}
+		b.emit(FlagChangedEvent{Row: row, Col: col, Flagged: b.Cells[row][col].IsFlagged})
+	}
+}
 
-		fmt.Println()
+// Display prints the board to stdout with the renderer selected by the
+// ANSI display option. If showMines is true the EndView is shown, otherwise
+// the PlayerView.
+func (b *Board) Display(showMines bool) {
+	view := b.PlayerView()
+	if showMines {
+		view = b.EndView()
 	}
 
-	if *b.DisplayOptions.BottomIndex {
-		fmt.Print("   ")
+	NewRenderer(*b.DisplayOptions.ANSI).Render(os.Stdout, view, b.DisplayOptions)
+}
 
-		for c := 0; c < b.Cols; c++ {
-			b.Printf("\x1b[34m%2d\x1b[0m ", c+startIndex)
-		}
+// Printf formats like fmt.Printf to stdout. ANSI escape codes are removed
+// when the ANSI display option is off.
+func (b *Board) Printf(format string, a ...any) {
+	b.Fprintf(os.Stdout, format, a...)
+}
 
-		fmt.Println()
-	}
+func (b *Board) Print(a ...any) {
+	b.Fprint(os.Stdout, a...)
 }
 
-func (b *Board) Printf(format string, a ...any) {
+func (b *Board) Println(a ...any) {
+	b.Fprintln(os.Stdout, a...)
+}
+
+// Fprintf is Printf writing to w.
+func (b *Board) Fprintf(w io.Writer, format string, a ...any) {
 	if !*b.DisplayOptions.ANSI {
 		format = RemoveAnsiEscapeCodes(format)
-
-		for i, v := range a {
-			t := reflect.TypeOf(v)
-
-			if t.Kind() == reflect.String {
-				a[i] = RemoveAnsiEscapeCodes(v.(string))
-			} else {
-				a[i] = v
-			}
-		}
+		stripStrings(a)
 	}
 
-	fmt.Printf(format, a...)
+	fmt.Fprintf(w, format, a...)
 }
 
-func (b *Board) Print(a ...any) {
+func (b *Board) Fprint(w io.Writer, a ...any) {
 	if !*b.DisplayOptions.ANSI {
-		for i, v := range a {
-			t := reflect.TypeOf(v)
-
-			if t.Kind() == reflect.String {
-				a[i] = RemoveAnsiEscapeCodes(v.(string))
-			} else {
-				a[i] = v
-			}
-		}
+		stripStrings(a)
 	}
 
-	fmt.Print(a...)
+	fmt.Fprint(w, a...)
 }
 
-func (b *Board) Println(a ...any) {
+func (b *Board) Fprintln(w io.Writer, a ...any) {
 	if !*b.DisplayOptions.ANSI {
-		for i, v := range a {
-			t := reflect.TypeOf(v)
+		stripStrings(a)
+	}
 
-			if t.Kind() == reflect.String {
-				a[i] = RemoveAnsiEscapeCodes(v.(string))
-			} else {
-				a[i] = v
-			}
+	fmt.Fprintln(w, a...)
+}
+
+// stripStrings removes ANSI escape codes from all strings in a.
+func stripStrings(a []any) {
+	for i, v := range a {
+		if s, ok := v.(string); ok {
+			a[i] = RemoveAnsiEscapeCodes(s)
 		}
 	}
-
-	fmt.Println(a...)
 }
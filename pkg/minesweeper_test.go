@@ -25,6 +25,12 @@ func TestNewBoard(t *testing.T) {
 	if board.NumMines != numMines {
 		t.Errorf("Expected NumMines to be %d, but got %d", numMines, board.NumMines)
 	}
+
+	// A board keeps a safe cell
+	full := minesweeper.NewBoard(2, 2, 4, boardOptions, nil)
+	if full.NumMines != 3 || full.RevealedPercentage() != 0 {
+		t.Errorf("Expected 3 mines and nothing cleared, but got %d mines and %f", full.NumMines, full.RevealedPercentage())
+	}
 }
 
 func TestPlaceMines(t *testing.T) {
@@ -149,3 +155,55 @@ func TestRevealAll(t *testing.T) {
 		t.Errorf("Expected revealed cells to be %d, but got %d", expectedRevealedCells, revealedCells)
 	}
 }
+
+func TestChord(t *testing.T) {
+	rows, cols, numMines := 10, 10, 10
+	boardOptions := &minesweeper.BoardOptions{Seed: 5}
+	displayOptions := &minesweeper.DisplayOptions{StartIndex: nil, ANSI: nil}
+
+	board := minesweeper.NewBoard(rows, cols, numMines, boardOptions, displayOptions)
+
+	// Find a revealed number next to the opening at (0, 0)
+	board.Reveal(0, 0)
+
+	row, col := -1, -1
+	for r := 0; r < rows && row < 0; r++ {
+		for c := 0; c < cols; c++ {
+			if board.Cells[r][c].IsRevealed && board.Cells[r][c].MinesAround > 0 {
+				row, col = r, c
+				break
+			}
+		}
+	}
+
+	if row < 0 {
+		t.Fatal("Expected a revealed number after revealing (0, 0)")
+	}
+
+	// Not enough flags, nothing happens
+	revealed := board.CellsRevealed()
+	if board.Chord(row, col) || board.CellsRevealed() != revealed {
+		t.Error("Expected Chord to do nothing without flags")
+	}
+
+	// Flag the mines around the number
+	for r := row - 1; r <= row+1; r++ {
+		for c := col - 1; c <= col+1; c++ {
+			if r >= 0 && r < rows && c >= 0 && c < cols && board.Cells[r][c].IsMine {
+				board.ToggleFlag(r, c)
+			}
+		}
+	}
+
+	if board.Chord(row, col) {
+		t.Error("Expected Chord to return false with the mines flagged")
+	}
+
+	for r := row - 1; r <= row+1; r++ {
+		for c := col - 1; c <= col+1; c++ {
+			if r >= 0 && r < rows && c >= 0 && c < cols && !board.Cells[r][c].IsMine && !board.Cells[r][c].IsRevealed {
+				t.Errorf("Expected cell (%d, %d) to be revealed by Chord", r, c)
+			}
+		}
+	}
+}
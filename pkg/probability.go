@@ -0,0 +1,388 @@
+package minesweeper
+
+import (
+	"math"
+	"sort"
+)
+
+// MaxEnumeratedCells is the largest group of hidden cells next to numbers
+// whose mines MineProbabilities counts exactly. Larger groups get an
+// estimate from the numbers around each cell.
+const MaxEnumeratedCells = 24
+
+// MineProbabilities returns the probability of every cell of the view to be
+// a mine, from what the player can see: revealed cells are 0 and flags are
+// taken as mines (1). Hidden cells next to numbers get the share of the
+// mine layouts that fit the numbers with a mine in the cell, the other
+// hidden cells share the mines that are left.
+func MineProbabilities(view *PlayerView) [][]float64 {
+	p := make([][]float64, view.Rows)
+	for r := range p {
+		p[r] = make([]float64, view.Cols)
+	}
+
+	hidden := func(r, c int) bool {
+		return view.Cells[r][c].State == CellHidden || view.Cells[r][c].State == CellMine
+	}
+
+	// The numbers with hidden cells around them
+	var constraints []constraint
+	frontier := make(map[int]bool)
+	flags := 0
+
+	for r := 0; r < view.Rows; r++ {
+		for c := 0; c < view.Cols; c++ {
+			state := view.Cells[r][c].State
+			if state == CellFlagged || state == CellExploded {
+				p[r][c] = 1
+				flags++
+				continue
+			}
+
+			if state != CellRevealed {
+				continue
+			}
+
+			con := constraint{mines: view.Cells[r][c].MinesAround}
+			forEachNeighbourOf(view.Rows, view.Cols, r, c, func(nr, nc int) {
+				switch {
+				case view.Cells[nr][nc].State == CellFlagged || view.Cells[nr][nc].State == CellExploded:
+					con.mines--
+				case hidden(nr, nc):
+					con.cells = append(con.cells, nr*view.Cols+nc)
+				}
+			})
+
+			if len(con.cells) > 0 {
+				constraints = append(constraints, con)
+				addAll(frontier, con.cells)
+			}
+		}
+	}
+
+	others := 0
+	for r := 0; r < view.Rows; r++ {
+		for c := 0; c < view.Cols; c++ {
+			if hidden(r, c) && !frontier[r*view.Cols+c] {
+				others++
+			}
+		}
+	}
+
+	minesLeft := view.NumMines - flags
+
+	groups := splitConstraints(constraints)
+	counts := make([]*layoutCount, len(groups))
+	for i, group := range groups {
+		counts[i] = countLayouts(group)
+	}
+
+	// all[k] is the weight of the layouts of all exact groups with k mines
+	all := []float64{1}
+	for _, count := range counts {
+		if count != nil {
+			all = convolve(all, count.layouts)
+		}
+	}
+
+	// weight returns the weight of k mines in the exact groups, the other
+	// mines are placed in the cells away from the numbers. The weights are
+	// scaled by the largest one, binomials of large boards overflow.
+	estimated := 0
+	for i, count := range counts {
+		if count == nil {
+			estimated += len(groups[i].cells)
+		}
+	}
+
+	away := others + estimated
+	maxLog := math.Inf(-1)
+	for k := range all {
+		if rest := minesLeft - k; rest >= 0 && rest <= away {
+			maxLog = math.Max(maxLog, logBinomial(away, rest))
+		}
+	}
+
+	weight := func(k int) float64 {
+		rest := minesLeft - k
+		if rest < 0 || rest > away {
+			return 0
+		}
+
+		return math.Exp(logBinomial(away, rest) - maxLog)
+	}
+
+	total := 0.0
+	expectedRest := 0.0
+	for k, w := range all {
+		total += w * weight(k)
+		expectedRest += w * weight(k) * float64(minesLeft-k)
+	}
+
+	if total == 0 {
+		return p
+	}
+
+	// The cells away from the numbers share the mines that are left
+	density := 0.0
+	if away > 0 {
+		density = expectedRest / total / float64(away)
+	}
+
+	for r := 0; r < view.Rows; r++ {
+		for c := 0; c < view.Cols; c++ {
+			if hidden(r, c) && !frontier[r*view.Cols+c] {
+				p[r][c] = density
+			}
+		}
+	}
+
+	for i, group := range groups {
+		count := counts[i]
+
+		if count == nil {
+			for _, cell := range group.cells {
+				p[cell/view.Cols][cell%view.Cols] = estimate(group, cell)
+			}
+
+			continue
+		}
+
+		// The layouts of the other groups
+		rest := []float64{1}
+		for j, other := range counts {
+			if j != i && other != nil {
+				rest = convolve(rest, other.layouts)
+			}
+		}
+
+		for k := range count.layouts {
+			w := 0.0
+			for s, layouts := range rest {
+				w += layouts * weight(k+s)
+			}
+
+			for cell, mines := range count.mines[k] {
+				p[cell/view.Cols][cell%view.Cols] += mines * w / total
+			}
+		}
+	}
+
+	return p
+}
+
+// constraintGroup is a group of numbers that share hidden cells.
+type constraintGroup struct {
+	constraints []constraint
+	cells       []int
+}
+
+// layoutCount is the number of mine layouts of a group that fit its
+// numbers. layouts[k] is the number of layouts with k mines, mines[k][cell]
+// the number of those with a mine in the cell.
+type layoutCount struct {
+	layouts []float64
+	mines   []map[int]float64
+}
+
+// splitConstraints groups the constraints that share cells.
+func splitConstraints(constraints []constraint) []*constraintGroup {
+	// Every constraint starts in a group of its own, constraints with a
+	// common cell are joined
+	parent := make([]int, len(constraints))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	root := func(i int) int {
+		for parent[i] != i {
+			i = parent[i]
+		}
+
+		return i
+	}
+
+	owner := make(map[int]int)
+	for i, con := range constraints {
+		for _, cell := range con.cells {
+			if j, ok := owner[cell]; ok {
+				parent[root(j)] = root(i)
+			} else {
+				owner[cell] = i
+			}
+		}
+	}
+
+	var groups []*constraintGroup
+	groupOf := make(map[int]*constraintGroup)
+
+	for i, con := range constraints {
+		group := groupOf[root(i)]
+		if group == nil {
+			group = &constraintGroup{}
+			groupOf[root(i)] = group
+			groups = append(groups, group)
+		}
+
+		group.constraints = append(group.constraints, con)
+	}
+
+	for cell, i := range owner {
+		group := groupOf[root(i)]
+		group.cells = append(group.cells, cell)
+	}
+
+	// Map order would make the counts differ between calls
+	for _, group := range groups {
+		sort.Ints(group.cells)
+	}
+
+	return groups
+}
+
+// countLayouts counts the mine layouts of the group that fit its numbers.
+// It returns nil if the group has more than MaxEnumeratedCells cells.
+func countLayouts(group *constraintGroup) *layoutCount {
+	if len(group.cells) > MaxEnumeratedCells {
+		return nil
+	}
+
+	index := make(map[int]int, len(group.cells))
+	for i, cell := range group.cells {
+		index[cell] = i
+	}
+
+	// The constraints of every cell and the cells left unassigned in every
+	// constraint
+	cellConstraints := make([][]int, len(group.cells))
+	for i, con := range group.constraints {
+		for _, cell := range con.cells {
+			cellConstraints[index[cell]] = append(cellConstraints[index[cell]], i)
+		}
+	}
+
+	need := make([]int, len(group.constraints))
+	open := make([]int, len(group.constraints))
+	for i, con := range group.constraints {
+		need[i] = con.mines
+		open[i] = len(con.cells)
+	}
+
+	count := &layoutCount{
+		layouts: make([]float64, len(group.cells)+1),
+		mines:   make([]map[int]float64, len(group.cells)+1),
+	}
+
+	for k := range count.mines {
+		count.mines[k] = make(map[int]float64)
+	}
+
+	mine := make([]bool, len(group.cells))
+
+	var place func(i, mines int)
+	place = func(i, mines int) {
+		if i == len(group.cells) {
+			count.layouts[mines]++
+			for j, isMine := range mine {
+				if isMine {
+					count.mines[mines][group.cells[j]]++
+				}
+			}
+
+			return
+		}
+
+		for _, isMine := range []bool{false, true} {
+			ok := true
+			for _, c := range cellConstraints[i] {
+				left := need[c]
+				if isMine {
+					left--
+				}
+
+				// Not enough cells left for the mines, or too many mines
+				if left < 0 || left > open[c]-1 {
+					ok = false
+				}
+			}
+
+			if !ok {
+				continue
+			}
+
+			for _, c := range cellConstraints[i] {
+				open[c]--
+				if isMine {
+					need[c]--
+				}
+			}
+
+			mine[i] = isMine
+			if isMine {
+				place(i+1, mines+1)
+			} else {
+				place(i+1, mines)
+			}
+			mine[i] = false
+
+			for _, c := range cellConstraints[i] {
+				open[c]++
+				if isMine {
+					need[c]++
+				}
+			}
+		}
+	}
+
+	place(0, 0)
+
+	return count
+}
+
+// estimate returns the highest share of mines of the numbers around the
+// cell, for groups too large to count.
+func estimate(group *constraintGroup, cell int) float64 {
+	p := 0.0
+	for _, con := range group.constraints {
+		for _, c := range con.cells {
+			if c == cell {
+				p = math.Max(p, float64(con.mines)/float64(len(con.cells)))
+			}
+		}
+	}
+
+	return p
+}
+
+// convolve returns the distribution of the sum of the mines of a and b.
+func convolve(a, b []float64) []float64 {
+	sum := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		for j, y := range b {
+			sum[i+j] += x * y
+		}
+	}
+
+	return sum
+}
+
+// logBinomial returns the logarithm of n choose k.
+func logBinomial(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+
+	return a - b - c
+}
+
+// forEachNeighbourOf calls fn for the neighbours of the cell at row, col on
+// a board of the size.
+func forEachNeighbourOf(rows, cols, row, col int, fn func(r, c int)) {
+	for r := row - 1; r <= row+1; r++ {
+		for c := col - 1; c <= col+1; c++ {
+			if (r != row || c != col) && r >= 0 && r < rows && c >= 0 && c < cols {
+				fn(r, c)
+			}
+		}
+	}
+}
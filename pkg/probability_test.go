@@ -0,0 +1,53 @@
+package minesweeper_test
+
+import (
+	"math"
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+// rowView returns a view of a board with one row, "." is hidden and digits
+// are revealed numbers.
+func rowView(cells string, mines int) *minesweeper.PlayerView {
+	view := &minesweeper.PlayerView{Rows: 1, Cols: len(cells), NumMines: mines, Cells: [][]minesweeper.CellView{make([]minesweeper.CellView, len(cells))}}
+
+	for i, c := range cells {
+		switch c {
+		case '.':
+			view.Cells[0][i] = minesweeper.CellView{State: minesweeper.CellHidden}
+		case 'F':
+			view.Cells[0][i] = minesweeper.CellView{State: minesweeper.CellFlagged}
+		default:
+			view.Cells[0][i] = minesweeper.CellView{State: minesweeper.CellRevealed, MinesAround: int(c - '0')}
+		}
+	}
+
+	return view
+}
+
+func TestMineProbabilities(t *testing.T) {
+	tests := []struct {
+		cells string
+		mines int
+		want  []float64
+	}{
+		{"1..", 1, []float64{0, 1, 0}},
+		{".1.", 1, []float64{0.5, 0, 0.5}},
+		// The mine left over is in the cell away from the number
+		{".1..", 2, []float64{0.5, 0, 0.5, 1}},
+		{"F1..", 2, []float64{1, 0, 0, 1}},
+		{"....", 1, []float64{0.25, 0.25, 0.25, 0.25}},
+	}
+
+	for _, test := range tests {
+		p := minesweeper.MineProbabilities(rowView(test.cells, test.mines))
+
+		for i, want := range test.want {
+			if math.Abs(p[0][i]-want) > 1e-9 {
+				t.Errorf("%s with %d mines: expected %v, got %v", test.cells, test.mines, test.want, p[0])
+				break
+			}
+		}
+	}
+}
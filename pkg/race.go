@@ -0,0 +1,169 @@
+package minesweeper
+
+import (
+	"sort"
+	"time"
+)
+
+// Racer is a player of a race with a board of their own.
+type Racer struct {
+	Name  string
+	Board *Board
+
+	// Time of the first reveal and the end of the racer's game
+	Started time.Time
+	Ended   time.Time
+
+	// Left is set once the racer left the race, the result still counts
+	Left bool
+}
+
+// Progress returns the share of safe cells the racer cleared, between 0 and 1.
+func (r *Racer) Progress() float64 {
+	return r.Board.RevealedPercentage()
+}
+
+// Finished reports whether the racer's game is over.
+func (r *Racer) Finished() bool {
+	return r.Board.Status() != StatusPlaying
+}
+
+// Time returns the time the racer played, up to now if the game is not over.
+func (r *Racer) Time() time.Duration {
+	if r.Started.IsZero() {
+		return 0
+	}
+
+	if r.Ended.IsZero() {
+		return time.Since(r.Started)
+	}
+
+	return r.Ended.Sub(r.Started)
+}
+
+// Race is a race of players on identical boards. Every racer gets a board
+// of their own with the same size, mines, seed, first-click rule and
+// generator version, so the mines are in the same cells. The fastest racer to clear the board wins, if nobody does the
+// racer who cleared the most.
+//
+// A race is not safe for concurrent use, like the boards of its racers.
+type Race struct {
+	Rows     int
+	Cols     int
+	NumMines int
+	Seed     int64
+
+	// FirstClick and Version are the rules the boards are generated with,
+	// see GeneratorVersion
+	FirstClick FirstClickRule
+	Version    int
+
+	racers []*Racer
+}
+
+// NewRace creates a race without racers on boards of this version.
+func NewRace(rows, cols, numMines int, seed int64) *Race {
+	return &Race{
+		Rows:       rows,
+		Cols:       cols,
+		NumMines:   numMines,
+		Seed:       seed,
+		FirstClick: FirstClickAny,
+		Version:    GeneratorVersion,
+	}
+}
+
+// Join adds a racer with a new board. A number is added to names that are
+// taken, an empty name becomes "player". It fails with ErrRaceVersion if
+// the race's boards are generated with another first-click rule or version,
+// the racer would get a different board.
+func (r *Race) Join(name string, displayOptions *DisplayOptions) (*Racer, error) {
+	if r.FirstClick != FirstClickAny || r.Version != GeneratorVersion {
+		return nil, ErrRaceVersion
+	}
+
+	racer := &Racer{
+		Name:  uniqueName(name, func(name string) bool { return r.Racer(name) != nil }),
+		Board: NewBoard(r.Rows, r.Cols, r.NumMines, &BoardOptions{Seed: r.Seed}, displayOptions),
+	}
+
+	racer.Board.Subscribe(func(event Event) {
+		switch event.(type) {
+		case GameStartedEvent:
+			racer.Started = time.Now()
+		case MineHitEvent, GameWonEvent:
+			racer.Ended = time.Now()
+		}
+	})
+
+	r.racers = append(r.racers, racer)
+
+	return racer, nil
+}
+
+// Racer returns the racer with the name (case insensitive), or nil.
+func (r *Race) Racer(name string) *Racer {
+	for _, racer := range r.racers {
+		if equalName(racer.Name, name) {
+			return racer
+		}
+	}
+
+	return nil
+}
+
+// Leave marks the racer as gone.
+func (r *Race) Leave(racer *Racer) {
+	racer.Left = true
+}
+
+// Racers returns the racers in the order they joined.
+func (r *Race) Racers() []*Racer {
+	return append([]*Racer(nil), r.racers...)
+}
+
+// Over reports whether every racer that didn't leave finished the game.
+func (r *Race) Over() bool {
+	for _, racer := range r.racers {
+		if !racer.Left && !racer.Finished() {
+			return false
+		}
+	}
+
+	return len(r.racers) > 0
+}
+
+// Standings returns the racers from first to last: the racers who cleared
+// the board by time, then the others by progress.
+func (r *Race) Standings() []*Racer {
+	standings := r.Racers()
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+
+		aWon := a.Board.Status() == StatusWon
+		bWon := b.Board.Status() == StatusWon
+
+		switch {
+		case aWon != bWon:
+			return aWon
+		case aWon:
+			return a.Time() < b.Time()
+		case a.Progress() != b.Progress():
+			return a.Progress() > b.Progress()
+		}
+
+		return a.Time() < b.Time()
+	})
+
+	return standings
+}
+
+// Winner returns the winner once the race is over, nil before.
+func (r *Race) Winner() *Racer {
+	if !r.Over() {
+		return nil
+	}
+
+	return r.Standings()[0]
+}
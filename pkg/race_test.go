@@ -0,0 +1,114 @@
+package minesweeper_test
+
+import (
+	"errors"
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestRace(t *testing.T) {
+	race := minesweeper.NewRace(5, 5, 3, 7)
+
+	join := func(name string) *minesweeper.Racer {
+		t.Helper()
+
+		racer, err := race.Join(name, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return racer
+	}
+
+	alice := join("alice")
+	bob := join("bob")
+	carol := join("carol")
+
+	// Every racer gets the same mines
+	for r := 0; r < race.Rows; r++ {
+		for c := 0; c < race.Cols; c++ {
+			if alice.Board.Cells[r][c].IsMine != bob.Board.Cells[r][c].IsMine || alice.Board.Cells[r][c].IsMine != carol.Board.Cells[r][c].IsMine {
+				t.Fatalf("expected identical boards, cell %d, %d differs", r, c)
+			}
+		}
+	}
+
+	var mine, number minesweeper.Position
+	for r := 0; r < race.Rows; r++ {
+		for c := 0; c < race.Cols; c++ {
+			switch cell := alice.Board.Cells[r][c]; {
+			case cell.IsMine:
+				mine = minesweeper.Position{Row: r, Col: c}
+			case cell.MinesAround > 0:
+				number = minesweeper.Position{Row: r, Col: c}
+			}
+		}
+	}
+
+	// bob clears a cell before losing, carol loses at once
+	bob.Board.Reveal(number.Row, number.Col)
+	bob.Board.Reveal(mine.Row, mine.Col)
+	carol.Board.Reveal(mine.Row, mine.Col)
+
+	if race.Over() || race.Winner() != nil {
+		t.Fatal("expected the race to go on while alice plays")
+	}
+
+	// Nobody cleared the board, the furthest progress wins
+	race.Leave(alice)
+
+	if !race.Over() || race.Winner() != bob {
+		t.Errorf("expected bob to win by progress, got %+v", race.Winner())
+	}
+
+	// Clearing the board beats any progress
+	dave := join("Bob")
+	if dave.Name != "Bob2" {
+		t.Errorf("expected a unique name, got %q", dave.Name)
+	}
+
+	for r := 0; r < race.Rows; r++ {
+		for c := 0; c < race.Cols; c++ {
+			if !dave.Board.Cells[r][c].IsMine {
+				dave.Board.Reveal(r, c)
+			}
+		}
+	}
+
+	if standings := race.Standings(); race.Winner() != dave || standings[1] != bob {
+		t.Errorf("expected dave before bob, got %v", names(standings))
+	}
+}
+
+func TestRaceVersion(t *testing.T) {
+	race := minesweeper.NewRace(5, 5, 3, 7)
+	if race.FirstClick != minesweeper.FirstClickAny || race.Version != minesweeper.GeneratorVersion {
+		t.Errorf("expected the rules of this version, got %s and %d", race.FirstClick, race.Version)
+	}
+
+	// A race of another version would give the racers other boards
+	race.Version = minesweeper.GeneratorVersion + 1
+	if _, err := race.Join("alice", nil); !errors.Is(err, minesweeper.ErrRaceVersion) {
+		t.Errorf("expected %v, got %v", minesweeper.ErrRaceVersion, err)
+	}
+
+	race.Version = minesweeper.GeneratorVersion
+	race.FirstClick = minesweeper.FirstClickAny + 1
+	if _, err := race.Join("alice", nil); !errors.Is(err, minesweeper.ErrRaceVersion) {
+		t.Errorf("expected %v, got %v", minesweeper.ErrRaceVersion, err)
+	}
+
+	if len(race.Racers()) != 0 {
+		t.Errorf("expected no racers, got %v", names(race.Racers()))
+	}
+}
+
+func names(racers []*minesweeper.Racer) []string {
+	var names []string
+	for _, racer := range racers {
+		names = append(names, racer.Name)
+	}
+
+	return names
+}
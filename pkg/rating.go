@@ -0,0 +1,154 @@
+package minesweeper
+
+import "math"
+
+// Rating describes how difficult a board is to clear.
+type Rating struct {
+	// BV3 is the Bechtel's Board Benchmark Value (3BV) of the board, the
+	// minimum number of clicks needed to clear it without flags.
+	BV3 int
+	// Openings is the number of connected areas of cells without mines around them.
+	Openings int
+	// LargestOpening is the number of cells the largest opening reveals.
+	LargestOpening int
+	// Guesses is the number of guesses the solver needed after the first click.
+	Guesses int
+	// HardestRule is the hardest rule the solver had to use.
+	HardestRule SolverRule
+	// Score combines the values above into one difficulty score, higher is harder.
+	Score float64
+}
+
+// Weights of the hardest solver rule in the difficulty score
+var ruleWeights = map[SolverRule]float64{
+	RuleNone:   0,
+	RuleSingle: 0.5,
+	RuleSubset: 1.5,
+	RuleGuess:  3,
+}
+
+// Rate rates the difficulty of the board. The state of the board (revealed
+// or flagged cells) is ignored.
+//
+// The score adds up:
+//   - the 3BV per safe cell times 10
+//   - 2 for every guess
+//   - the weight of the hardest rule (0.5 single, 1.5 subset, 3 guess)
+//   - up to 2 for how little of the board the largest opening reveals
+func Rate(b *Board) Rating {
+	safeCells := b.Rows*b.Cols - b.NumMines
+	if safeCells <= 0 {
+		return Rating{}
+	}
+
+	openings, largest, _ := b.openings()
+	solved := Solve(b)
+
+	rating := Rating{
+		BV3:            b.BV3(),
+		Openings:       openings,
+		LargestOpening: largest,
+		Guesses:        solved.Guesses,
+		HardestRule:    solved.HardestRule,
+	}
+
+	score := 10 * float64(rating.BV3) / float64(safeCells)
+	score += 2 * float64(rating.Guesses)
+	score += ruleWeights[rating.HardestRule]
+	score += 2 * (1 - float64(largest)/float64(safeCells))
+
+	rating.Score = math.Round(score*10) / 10
+
+	return rating
+}
+
+// BV3 returns the 3BV of the board: the number of openings plus the number of
+// safe cells that are not revealed by any opening.
+func (b *Board) BV3() int {
+	openings, _, _ := b.openings()
+	bv3 := openings
+
+	for r := 0; r < b.Rows; r++ {
+		for c := 0; c < b.Cols; c++ {
+			if !b.Cells[r][c].IsMine && !b.touchesOpening(r, c) {
+				bv3++
+			}
+		}
+	}
+
+	return bv3
+}
+
+// touchesOpening reports whether the cell is part of an opening or borders one.
+func (b *Board) touchesOpening(row, col int) bool {
+	touches := false
+
+	b.forEachCell(row, col, func(r, c int) {
+		if b.isOpening(r, c) {
+			touches = true
+		}
+	})
+
+	return touches
+}
+
+// openings returns the number of openings, the number of cells revealed by
+// the largest one and the index (row*Cols+col) of a cell in it. start is -1
+// if the board has no openings.
+func (b *Board) openings() (count, largest, start int) {
+	seen := make([][]bool, b.Rows)
+	for r := range seen {
+		seen[r] = make([]bool, b.Cols)
+	}
+
+	start = -1
+
+	for r := 0; r < b.Rows; r++ {
+		for c := 0; c < b.Cols; c++ {
+			if seen[r][c] || !b.isOpening(r, c) {
+				continue
+			}
+
+			count++
+
+			if size := b.floodSize(r, c, seen); size > largest {
+				largest, start = size, r*b.Cols+c
+			}
+		}
+	}
+
+	return count, largest, start
+}
+
+func (b *Board) isOpening(row, col int) bool {
+	return !b.Cells[row][col].IsMine && b.Cells[row][col].MinesAround == 0
+}
+
+// floodSize counts the cells revealed when clicking the opening at row, col.
+// Cells are marked in seen, border numbers are counted but not marked so
+// neighbouring openings can share them.
+func (b *Board) floodSize(row, col int, seen [][]bool) int {
+	size := 0
+	counted := make(map[int]bool)
+	stack := [][2]int{{row, col}}
+	seen[row][col] = true
+
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		b.forEachCell(cell[0], cell[1], func(r, c int) {
+			if !counted[r*b.Cols+c] {
+				counted[r*b.Cols+c] = true
+				size++
+			}
+
+			if !seen[r][c] && b.isOpening(r, c) {
+				seen[r][c] = true
+				stack = append(stack, [2]int{r, c})
+			}
+		})
+	}
+
+	return size
+}
@@ -0,0 +1,99 @@
+package minesweeper_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestBV3(t *testing.T) {
+	// One mine in the corner of a 3 X 3 board, one click clears it
+	board := &minesweeper.Board{
+		Rows:     3,
+		Cols:     3,
+		NumMines: 1,
+		Cells: [][]minesweeper.Cell{
+			{{IsMine: true}, {MinesAround: 1}, {}},
+			{{MinesAround: 1}, {MinesAround: 1}, {}},
+			{{}, {}, {}},
+		},
+	}
+
+	if bv3 := board.BV3(); bv3 != 1 {
+		t.Errorf("Expected 3BV to be %d, but got %d", 1, bv3)
+	}
+
+	rating := minesweeper.Rate(board)
+	if rating.Guesses != 0 {
+		t.Errorf("Expected guesses to be %d, but got %d", 0, rating.Guesses)
+	}
+
+	if rating.HardestRule != minesweeper.RuleNone {
+		t.Errorf("Expected hardest rule to be %s, but got %s", minesweeper.RuleNone, rating.HardestRule)
+	}
+}
+
+func TestRate(t *testing.T) {
+	rows, cols, numMines := 10, 10, 10
+	boardOptions := &minesweeper.BoardOptions{Seed: 5}
+	displayOptions := &minesweeper.DisplayOptions{StartIndex: nil, ANSI: nil}
+
+	board := minesweeper.NewBoard(rows, cols, numMines, boardOptions, displayOptions)
+
+	rating := minesweeper.Rate(board)
+
+	if rating.BV3 < 1 || rating.BV3 > rows*cols-numMines {
+		t.Errorf("Expected 3BV to be between 1 and %d, but got %d", rows*cols-numMines, rating.BV3)
+	}
+
+	if rating.Score <= 0 {
+		t.Errorf("Expected score to be positive, but got %f", rating.Score)
+	}
+
+	// Rating must not change the board
+	if revealed := board.CellsRevealed(); revealed != 0 {
+		t.Errorf("Expected revealed cells to be %d, but got %d", 0, revealed)
+	}
+}
+
+func TestNewBoardDifficultyRange(t *testing.T) {
+	rows, cols, numMines := 16, 16, 40
+	boardOptions := &minesweeper.BoardOptions{Seed: 5, MinDifficulty: 6, MaxDifficulty: 8}
+
+	board := minesweeper.NewBoard(rows, cols, numMines, boardOptions, nil)
+
+	score := minesweeper.Rate(board).Score
+	if score < 6 || score > 8 {
+		t.Errorf("Expected score to be between 6 and 8, but got %f", score)
+	}
+}
+
+func TestNewBoardContextOutOfRange(t *testing.T) {
+	// No board is that hard, the search stops when ctx is done
+	boardOptions := &minesweeper.BoardOptions{Seed: 5, MinDifficulty: 1000}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	board, err := minesweeper.NewBoardContext(ctx, 16, 16, 40, boardOptions, nil)
+
+	if !errors.Is(err, minesweeper.ErrDifficultyRange) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected ErrDifficultyRange after the deadline, but got %v", err)
+	}
+
+	if board == nil || board.NumMines != 40 {
+		t.Errorf("Expected the last board, but got %+v", board)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the search to stop after 50ms, but it took %s", elapsed)
+	}
+
+	if _, err := minesweeper.NewBoardContext(context.Background(), 9, 9, 10, &minesweeper.BoardOptions{Seed: 5, MaxDifficulty: 100}, nil); err != nil {
+		t.Errorf("Expected a board in range, but got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package minesweeper
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Renderer renders the board as seen by the player to a writer.
+type Renderer interface {
+	// Render writes the cells of the view to w, laid out with the display
+	// options. Options that are nil, or nil options, use the defaults of
+	// NewBoard.
+	Render(w io.Writer, view *PlayerView, options *DisplayOptions) error
+}
+
+// ANSIRenderer renders the board with ANSI escape codes for colors.
+type ANSIRenderer struct {
+	// Cursor is the cell drawn in reverse video, nil draws no cursor.
+	Cursor *Position
+	// Cursors are cells drawn on the background of their color, like the
+	// cursors of the other players of a team.
+	Cursors []Cursor
+}
+
+// Cursor is a cell highlighted with a color.
+type Cursor struct {
+	Position
+	Color Color
+}
+
+// PlainRenderer renders the board as plain text without any escape codes.
+type PlainRenderer struct{}
+
+// NewRenderer returns an ANSIRenderer if ansi is true and a PlainRenderer otherwise.
+func NewRenderer(ansi bool) Renderer {
+	if ansi {
+		return ANSIRenderer{}
+	}
+
+	return PlainRenderer{}
+}
+
+// Render draws the board with the Theme and ColorDepth of the display options.
+func (r ANSIRenderer) Render(w io.Writer, view *PlayerView, options *DisplayOptions) error {
+	theme := ThemeClassic
+	if options != nil && options.Theme != nil {
+		theme = *options.Theme
+	}
+
+	depth := Color16
+	if options != nil && options.ColorDepth != nil {
+		depth = *options.ColorDepth
+	}
+
+	return render(w, view, options, &theme, r.Cursor, r.Cursors, func(style Style, text string) string {
+		return style.Paint(text, depth)
+	})
+}
+
+func (PlainRenderer) Render(w io.Writer, view *PlayerView, options *DisplayOptions) error {
+	return render(w, view, options, &Theme{}, nil, nil, func(style Style, text string) string {
+		return text
+	})
+}
+
+// render writes the cells of the view to w. paint is called to style every
+// part of the board. The style of the cell at cursor is reversed, the cells
+// of the cursors get their color as background.
+func render(w io.Writer, playerView *PlayerView, options *DisplayOptions, theme *Theme, cursor *Position, cursors []Cursor, paint func(style Style, text string) string) error {
+	var sb strings.Builder
+
+	// The layout only depends on the size of the board and the options
+	b := &Board{Rows: playerView.Rows, Cols: playerView.Cols, DisplayOptions: options.withDefaults()}
+
+	symbolMine := *b.DisplayOptions.SymbolMine
+	symbolFlag := *b.DisplayOptions.SymbolFlag
+	symbolHidden := *b.DisplayOptions.SymbolHidden
+	symbolSeperator := *b.DisplayOptions.SymbolSeperator
+
+	view := b.view()
+	layout := b.Layout()
+	lastCol := view.Col + view.Cols - 1
+
+	var minimap []string
+	if b.DisplayOptions.Minimap != nil && *b.DisplayOptions.Minimap {
+		minimap = b.minimap(view)
+	}
+
+	// colIndex writes the column indices, right aligned with their cells
+	colIndex := func() {
+		sb.WriteString(strings.Repeat(" ", layout.Gutter+layout.CellWidth-layout.ColIndexWidth))
+
+		for c := view.Col; c <= lastCol; c++ {
+			sb.WriteString(paint(theme.Index, padLeft(b.ColLabel(c), layout.ColIndexWidth)))
+			sb.WriteString(strings.Repeat(" ", layout.Pitch()-layout.ColIndexWidth))
+		}
+
+		sb.WriteString("\n")
+	}
+
+	// The line of the top index is padding without it
+	if *b.DisplayOptions.TopIndex {
+		colIndex()
+	} else {
+		sb.WriteString(strings.Repeat(" ", layout.Gutter) + "\n")
+	}
+
+	for r := view.Row; r < view.Row+view.Rows; r++ {
+		if *b.DisplayOptions.LeftIndex {
+			sb.WriteString(paint(theme.Index, padLeft(b.RowLabel(r), layout.RowIndexWidth)) + "| ")
+		} else {
+			sb.WriteString(strings.Repeat(" ", layout.Gutter))
+		}
+
+		for c := view.Col; c <= lastCol; c++ {
+			cell := playerView.Cells[r][c]
+
+			var style Style
+			var text string
+
+			switch cell.State {
+			case CellExploded:
+				style, text = theme.Exploded, symbolMine
+			case CellMine:
+				style, text = theme.Mine, symbolMine
+			case CellRevealed:
+				style, text = theme.Numbers[cell.MinesAround], strconv.Itoa(cell.MinesAround)
+			case CellFlagged:
+				style, text = theme.Flag, symbolFlag
+			default:
+				style, text = theme.Hidden, symbolHidden
+			}
+
+			for _, other := range cursors {
+				if other.Row == r && other.Col == c {
+					style.Foreground, style.Background = "black", other.Color
+				}
+			}
+
+			if cursor != nil && cursor.Row == r && cursor.Col == c {
+				style.Reverse = true
+			}
+
+			sb.WriteString(paint(style, padLeft(text, layout.CellWidth)))
+
+			if c < lastCol {
+				sb.WriteString(symbolSeperator)
+			}
+		}
+
+		if *b.DisplayOptions.RightIndex {
+			index := b.RowLabel(r)
+
+			// Pad the index so the minimap lines up
+			if len(minimap) > 0 {
+				index = padRight(index, layout.RowIndexWidth)
+			}
+
+			sb.WriteString(" |" + paint(theme.Index, index))
+		}
+
+		// Show the minimap in the top right corner
+		if i := r - view.Row; i < len(minimap) {
+			sb.WriteString("   " + paint(theme.Index, minimap[i]))
+		}
+
+		sb.WriteString("\n")
+	}
+
+	if *b.DisplayOptions.BottomIndex {
+		colIndex()
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// CellAt returns the cell drawn at column x and line y of the rendered
+// board. Both are 0-based and relative to the first character written by the
+// renderer. Clicks on a separator belong to the nearest cell. Only cells in
+// the viewport are found.
+func (b *Board) CellAt(x, y int) (Position, bool) {
+	// The first line is the top index or padding
+	y--
+
+	view := b.view()
+	layout := b.Layout()
+
+	x += layout.SeparatorWidth/2 - layout.Gutter
+	if x < 0 || y < 0 || y >= view.Rows {
+		return Position{}, false
+	}
+
+	col := x / layout.Pitch()
+	if col >= view.Cols {
+		return Position{}, false
+	}
+
+	return Position{Row: view.Row + y, Col: view.Col + col}, true
+}
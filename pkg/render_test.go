@@ -0,0 +1,145 @@
+package minesweeper_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/TechMDW/minesweeper/internal/util"
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestPlainRenderer(t *testing.T) {
+	rows, cols, numMines := 10, 10, 10
+	boardOptions := &minesweeper.BoardOptions{Seed: 5}
+	displayOptions := &minesweeper.DisplayOptions{StartIndex: nil, ANSI: nil}
+
+	board := minesweeper.NewBoard(rows, cols, numMines, boardOptions, displayOptions)
+	board.ToggleFlag(9, 9)
+
+	var buf bytes.Buffer
+	if err := (minesweeper.PlainRenderer{}).Render(&buf, board.PlayerView(), board.DisplayOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	output := buf.String()
+
+	if strings.Contains(output, "\x1b[") {
+		t.Error("Expected plain output without ANSI escape codes")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != rows+1 {
+		t.Fatalf("Expected %d lines, but got %d", rows+1, len(lines))
+	}
+
+	expected := "10| •  •  •  •  •  •  •  •  •  F"
+	if lines[rows] != expected {
+		t.Errorf("Expected last row to be %q, but got %q", expected, lines[rows])
+	}
+}
+
+func TestRenderDefaultOptions(t *testing.T) {
+	board := minesweeper.NewBoard(3, 3, 1, &minesweeper.BoardOptions{Seed: 5}, nil)
+	board.ToggleFlag(2, 2)
+
+	var want bytes.Buffer
+	if err := (minesweeper.PlainRenderer{}).Render(&want, board.PlayerView(), board.DisplayOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	// Options that are not set are the defaults of NewBoard
+	renderers := []minesweeper.Renderer{minesweeper.PlainRenderer{}, minesweeper.ANSIRenderer{}}
+	for _, options := range []*minesweeper.DisplayOptions{{}, nil} {
+		for _, renderer := range renderers {
+			var buf bytes.Buffer
+			if err := renderer.Render(&buf, board.PlayerView(), options); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, plain := renderer.(minesweeper.PlainRenderer); plain && buf.String() != want.String() {
+				t.Errorf("Expected the default layout %q, but got %q", want.String(), buf.String())
+			}
+
+			if !strings.Contains(buf.String(), "F") {
+				t.Errorf("Expected the flag on the board, but got %q", buf.String())
+			}
+		}
+	}
+}
+
+func TestRenderWithoutTopIndex(t *testing.T) {
+	board := minesweeper.NewBoard(3, 3, 1, &minesweeper.BoardOptions{Seed: 5}, &minesweeper.DisplayOptions{TopIndex: util.BoolPtr(false)})
+
+	var buf bytes.Buffer
+	(minesweeper.PlainRenderer{}).Render(&buf, board.PlayerView(), board.DisplayOptions)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	// The line of the top index is kept as padding
+	if len(lines) != 4 || strings.TrimSpace(lines[0]) != "" || !strings.HasPrefix(lines[1], " 1|") {
+		t.Errorf("Expected a padding line before the rows, but got %q", lines)
+	}
+
+	if pos, ok := board.CellAt(4, 1); !ok || pos.Row != 0 || pos.Col != 0 {
+		t.Errorf("Expected cell (0, 0) on the second line, but got (%d, %d)", pos.Row, pos.Col)
+	}
+}
+
+func TestANSIRenderer(t *testing.T) {
+	rows, cols, numMines := 10, 10, 10
+	boardOptions := &minesweeper.BoardOptions{Seed: 5}
+	displayOptions := &minesweeper.DisplayOptions{StartIndex: nil, ANSI: nil}
+
+	board := minesweeper.NewBoard(rows, cols, numMines, boardOptions, displayOptions)
+
+	var ansi, plain bytes.Buffer
+	(minesweeper.ANSIRenderer{}).Render(&ansi, board.EndView(), board.DisplayOptions)
+	(minesweeper.PlainRenderer{}).Render(&plain, board.EndView(), board.DisplayOptions)
+
+	if !strings.Contains(ansi.String(), "\x1b[41mX\x1b[0m") {
+		t.Error("Expected mines to be rendered with a red background")
+	}
+
+	// Without the escape codes both renderers must produce the same board
+	if minesweeper.RemoveAnsiEscapeCodes(ansi.String()) != plain.String() {
+		t.Errorf("Expected ANSI output without escape codes to equal plain output, but got\n%s\n%s", ansi.String(), plain.String())
+	}
+}
+
+func TestCellAt(t *testing.T) {
+	rows, cols, numMines := 10, 10, 10
+	boardOptions := &minesweeper.BoardOptions{Seed: 5}
+	displayOptions := &minesweeper.DisplayOptions{StartIndex: nil, ANSI: nil}
+
+	board := minesweeper.NewBoard(rows, cols, numMines, boardOptions, displayOptions)
+
+	var buf bytes.Buffer
+	(minesweeper.PlainRenderer{}).Render(&buf, board.PlayerView(), board.DisplayOptions)
+	lines := strings.Split(buf.String(), "\n")
+
+	// Every hidden symbol in the output must map back to its cell
+	for y, line := range lines {
+		x := 0
+		for _, r := range line {
+			if string(r) == minesweeper.SymbolHidden {
+				pos, ok := board.CellAt(x, y)
+				if !ok {
+					t.Errorf("Expected a cell at (%d, %d)", x, y)
+				} else if pos.Row != y-1 || pos.Col != (x-4)/3 {
+					t.Errorf("Expected cell (%d, %d) at (%d, %d), but got (%d, %d)", y-1, (x-4)/3, x, y, pos.Row, pos.Col)
+				}
+			}
+
+			x++
+		}
+	}
+
+	// Index gutters are not cells
+	if _, ok := board.CellAt(0, 1); ok {
+		t.Error("Expected no cell in the left index")
+	}
+
+	if _, ok := board.CellAt(4, 0); ok {
+		t.Error("Expected no cell in the top index")
+	}
+}
@@ -0,0 +1,124 @@
+package minesweeper
+
+import "context"
+
+// Session makes a board safe to use from several goroutines. Moves are
+// serialized and reads get a consistent snapshot of the board.
+//
+// The board must not be used directly while it is owned by a session.
+type Session struct {
+	board *Board
+
+	// lock is a mutex that can be acquired with a context
+	lock chan struct{}
+}
+
+// NewSession creates a session that owns the board.
+func NewSession(board *Board) *Session {
+	return &Session{
+		board: board,
+		lock:  make(chan struct{}, 1),
+	}
+}
+
+// Do runs fn with exclusive access to the board. It returns the context's
+// error if the context is done before the board is available.
+func (s *Session) Do(ctx context.Context, fn func(board *Board) error) error {
+	select {
+	case s.lock <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	defer func() { <-s.lock }()
+
+	// The context may be done while both cases were ready
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return fn(s.board)
+}
+
+// Reveal reveals the cell at row, col. It returns true if a mine was revealed.
+func (s *Session) Reveal(ctx context.Context, row, col int) (mineHit bool, err error) {
+	err = s.Do(ctx, func(board *Board) error {
+		mineHit, err = board.TryReveal(row, col)
+		return err
+	})
+
+	return mineHit, err
+}
+
+// Chord reveals the hidden neighbours of the revealed cell at row, col if
+// enough flags are placed around it. It returns true if a mine was revealed.
+func (s *Session) Chord(ctx context.Context, row, col int) (mineHit bool, err error) {
+	err = s.Do(ctx, func(board *Board) error {
+		mineHit, err = board.TryChord(row, col)
+		return err
+	})
+
+	return mineHit, err
+}
+
+// ToggleFlag places or removes a flag on the cell at row, col.
+func (s *Session) ToggleFlag(ctx context.Context, row, col int) error {
+	return s.Do(ctx, func(board *Board) error {
+		return board.TryToggleFlag(row, col)
+	})
+}
+
+// TryReveal is Reveal returning an error for moves that are not allowed,
+// like the moves of a Session.
+func (b *Board) TryReveal(row, col int) (mineHit bool, err error) {
+	if err := b.checkMove(row, col); err != nil {
+		return false, err
+	}
+
+	if b.Cells[row][col].IsFlagged {
+		return false, ErrCellFlagged
+	}
+
+	return b.Reveal(row, col), nil
+}
+
+// TryChord is Chord returning an error for moves that are not allowed.
+func (b *Board) TryChord(row, col int) (mineHit bool, err error) {
+	if b.Status() != StatusPlaying {
+		return false, ErrGameOver
+	}
+
+	if row < 0 || row >= b.Rows || col < 0 || col >= b.Cols {
+		return false, ErrOutOfBounds
+	}
+
+	return b.Chord(row, col), nil
+}
+
+// TryToggleFlag is ToggleFlag returning an error for moves that are not
+// allowed.
+func (b *Board) TryToggleFlag(row, col int) error {
+	if err := b.checkMove(row, col); err != nil {
+		return err
+	}
+
+	b.ToggleFlag(row, col)
+	return nil
+}
+
+// checkMove returns an error if the cell at row, col can't be played.
+func (b *Board) checkMove(row, col int) error {
+	if b.Status() != StatusPlaying {
+		return ErrGameOver
+	}
+
+	if row < 0 || row >= b.Rows || col < 0 || col >= b.Cols {
+		return ErrOutOfBounds
+	}
+
+	if b.Cells[row][col].IsRevealed {
+		return ErrAlreadyRevealed
+	}
+
+	return nil
+}
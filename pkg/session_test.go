@@ -0,0 +1,141 @@
+package minesweeper_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestSessionConcurrentMoves(t *testing.T) {
+	rows, cols, numMines := 10, 10, 10
+	boardOptions := &minesweeper.BoardOptions{Seed: 5}
+	displayOptions := &minesweeper.DisplayOptions{StartIndex: nil, ANSI: nil}
+
+	board := minesweeper.NewBoard(rows, cols, numMines, boardOptions, displayOptions)
+
+	// Remember the safe cells before the session owns the board
+	var safe []minesweeper.Position
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			if !board.Cells[r][c].IsMine {
+				safe = append(safe, minesweeper.Position{Row: r, Col: c})
+			}
+		}
+	}
+
+	session := minesweeper.NewSession(board)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for _, pos := range safe {
+		wg.Add(3)
+
+		go func(pos minesweeper.Position) {
+			defer wg.Done()
+
+			session.Reveal(ctx, pos.Row, pos.Col)
+		}(pos)
+
+		go func(pos minesweeper.Position) {
+			defer wg.Done()
+
+			session.ToggleFlag(ctx, pos.Row, pos.Col)
+		}(pos)
+
+		go func() {
+			defer wg.Done()
+
+			view, err := session.PlayerView(ctx)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if view.Status == minesweeper.StatusLost {
+				t.Error("Expected the game not to be lost when only revealing safe cells")
+			}
+
+			// The mines are hidden while the game is played
+			for _, row := range view.Cells {
+				for _, cell := range row {
+					if view.Status == minesweeper.StatusPlaying && cell.State == minesweeper.CellMine {
+						t.Error("Expected no mines in the view of a game being played")
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// Flagged cells are skipped, reveal them now that no flags are toggled
+	for _, pos := range safe {
+		session.Do(ctx, func(board *minesweeper.Board) error {
+			if board.Cells[pos.Row][pos.Col].IsFlagged {
+				board.ToggleFlag(pos.Row, pos.Col)
+			}
+
+			return nil
+		})
+
+		session.Reveal(ctx, pos.Row, pos.Col)
+	}
+
+	view, err := session.PlayerView(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if view.Status != minesweeper.StatusWon {
+		t.Errorf("Expected status to be %s, but got %s", minesweeper.StatusWon, view.Status)
+	}
+
+	if view.Revealed != rows*cols-numMines {
+		t.Errorf("Expected revealed cells to be %d, but got %d", rows*cols-numMines, view.Revealed)
+	}
+}
+
+func TestSessionErrors(t *testing.T) {
+	rows, cols, numMines := 10, 10, 10
+	boardOptions := &minesweeper.BoardOptions{Seed: 5}
+	displayOptions := &minesweeper.DisplayOptions{StartIndex: nil, ANSI: nil}
+
+	session := minesweeper.NewSession(minesweeper.NewBoard(rows, cols, numMines, boardOptions, displayOptions))
+	ctx := context.Background()
+
+	if _, err := session.Reveal(ctx, rows, 0); !errors.Is(err, minesweeper.ErrOutOfBounds) {
+		t.Errorf("Expected %v, but got %v", minesweeper.ErrOutOfBounds, err)
+	}
+
+	session.Reveal(ctx, 0, 0)
+
+	if _, err := session.Reveal(ctx, 0, 0); !errors.Is(err, minesweeper.ErrAlreadyRevealed) {
+		t.Errorf("Expected %v, but got %v", minesweeper.ErrAlreadyRevealed, err)
+	}
+
+	session.ToggleFlag(ctx, 9, 9)
+
+	if _, err := session.Reveal(ctx, 9, 9); !errors.Is(err, minesweeper.ErrCellFlagged) {
+		t.Errorf("Expected %v, but got %v", minesweeper.ErrCellFlagged, err)
+	}
+
+	// Reveal cell with a mine
+	if mineHit, _ := session.Reveal(ctx, 9, 0); !mineHit {
+		t.Error("Expected Reveal to return true for a cell with a mine")
+	}
+
+	if err := session.ToggleFlag(ctx, 5, 5); !errors.Is(err, minesweeper.ErrGameOver) {
+		t.Errorf("Expected %v, but got %v", minesweeper.ErrGameOver, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if _, err := session.PlayerView(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, but got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,254 @@
+package minesweeper
+
+// SolverRule is a deduction rule used by the solver, ordered from easiest to hardest.
+type SolverRule int
+
+const (
+	// RuleNone means no deduction was needed, the first click cleared the board.
+	RuleNone SolverRule = iota
+	// RuleSingle means a single number decided all of its hidden neighbours.
+	RuleSingle
+	// RuleSubset means the hidden neighbours of one number were a subset of
+	// another number's and the difference decided the remaining cells.
+	RuleSubset
+	// RuleGuess means no rule could make progress and the solver had to guess.
+	RuleGuess
+)
+
+func (r SolverRule) String() string {
+	switch r {
+	case RuleNone:
+		return "none"
+	case RuleSingle:
+		return "single"
+	case RuleSubset:
+		return "subset"
+	case RuleGuess:
+		return "guess"
+	}
+
+	return "unknown"
+}
+
+// SolveResult describes how the solver cleared a board.
+type SolveResult struct {
+	// Guesses is the number of guesses needed after the first click.
+	Guesses int
+	// HardestRule is the hardest rule the solver had to use.
+	HardestRule SolverRule
+	// Steps is the number of rounds of deductions and guesses.
+	Steps int
+}
+
+// solver keeps track of what a player knows about a board. Deductions only
+// use the revealed numbers, the mines of the board are only read when a cell
+// is revealed.
+type solver struct {
+	board    *Board
+	revealed [][]bool
+	mine     [][]bool
+	left     int
+}
+
+// constraint is a revealed number and the hidden cells around it that are
+// not known to be mines.
+type constraint struct {
+	cells []int
+	mines int
+}
+
+func newSolver(b *Board) *solver {
+	s := &solver{
+		board:    b,
+		revealed: make([][]bool, b.Rows),
+		mine:     make([][]bool, b.Rows),
+		left:     b.Rows*b.Cols - b.NumMines,
+	}
+
+	for r := 0; r < b.Rows; r++ {
+		s.revealed[r] = make([]bool, b.Cols)
+		s.mine[r] = make([]bool, b.Cols)
+	}
+
+	return s
+}
+
+// Solve plays the board from scratch with the solver and reports how hard
+// it was. The board itself is not modified.
+//
+// The first click is made in the largest opening, or on the first safe cell
+// if the board has no openings.
+func Solve(b *Board) SolveResult {
+	s := newSolver(b)
+	result := SolveResult{}
+
+	if _, _, start := b.openings(); start >= 0 {
+		s.reveal(start/b.Cols, start%b.Cols)
+	} else {
+		s.guess()
+	}
+
+	for s.left > 0 {
+		result.Steps++
+
+		safe, mines, rule := s.deduce()
+		if len(safe) == 0 && len(mines) == 0 {
+			s.guess()
+			result.Guesses++
+			rule = RuleGuess
+		}
+
+		for _, cell := range mines {
+			s.mine[cell/b.Cols][cell%b.Cols] = true
+		}
+
+		for _, cell := range safe {
+			s.reveal(cell/b.Cols, cell%b.Cols)
+		}
+
+		if rule > result.HardestRule {
+			result.HardestRule = rule
+		}
+	}
+
+	return result
+}
+
+// reveal reveals a safe cell and floods openings like Board.Reveal.
+func (s *solver) reveal(row, col int) {
+	if s.revealed[row][col] {
+		return
+	}
+
+	s.revealed[row][col] = true
+	s.left--
+
+	if s.board.Cells[row][col].MinesAround == 0 {
+		s.board.forEachNeighbour(row, col, func(r, c int) {
+			s.reveal(r, c)
+		})
+	}
+}
+
+// guess reveals the first safe cell that is still hidden.
+func (s *solver) guess() {
+	for r := 0; r < s.board.Rows; r++ {
+		for c := 0; c < s.board.Cols; c++ {
+			if !s.revealed[r][c] && !s.board.Cells[r][c].IsMine {
+				s.reveal(r, c)
+				return
+			}
+		}
+	}
+}
+
+// constraints returns a constraint for every revealed number that still has
+// unknown hidden neighbours.
+func (s *solver) constraints() []constraint {
+	var constraints []constraint
+
+	for r := 0; r < s.board.Rows; r++ {
+		for c := 0; c < s.board.Cols; c++ {
+			if !s.revealed[r][c] {
+				continue
+			}
+
+			con := constraint{mines: s.board.Cells[r][c].MinesAround}
+
+			s.board.forEachNeighbour(r, c, func(nr, nc int) {
+				switch {
+				case s.mine[nr][nc]:
+					con.mines--
+				case !s.revealed[nr][nc]:
+					con.cells = append(con.cells, nr*s.board.Cols+nc)
+				}
+			})
+
+			if len(con.cells) > 0 {
+				constraints = append(constraints, con)
+			}
+		}
+	}
+
+	return constraints
+}
+
+// deduce returns cells that are known to be safe or mines using the easiest
+// rule that makes progress.
+func (s *solver) deduce() (safe, mines []int, rule SolverRule) {
+	constraints := s.constraints()
+
+	safeSet := make(map[int]bool)
+	mineSet := make(map[int]bool)
+
+	for _, con := range constraints {
+		if con.mines == 0 {
+			addAll(safeSet, con.cells)
+		} else if con.mines == len(con.cells) {
+			addAll(mineSet, con.cells)
+		}
+	}
+
+	if len(safeSet) > 0 || len(mineSet) > 0 {
+		return keys(safeSet), keys(mineSet), RuleSingle
+	}
+
+	for i, a := range constraints {
+		for j, b := range constraints {
+			if i == j || len(a.cells) >= len(b.cells) {
+				continue
+			}
+
+			diff, ok := difference(b.cells, a.cells)
+			if !ok {
+				continue
+			}
+
+			if b.mines == a.mines {
+				addAll(safeSet, diff)
+			} else if b.mines-a.mines == len(diff) {
+				addAll(mineSet, diff)
+			}
+		}
+	}
+
+	if len(safeSet) > 0 || len(mineSet) > 0 {
+		return keys(safeSet), keys(mineSet), RuleSubset
+	}
+
+	return nil, nil, RuleNone
+}
+
+// difference returns the cells of b that are not in a. ok is false if a is
+// not a subset of b.
+func difference(b, a []int) (diff []int, ok bool) {
+	inB := make(map[int]bool, len(b))
+	for _, cell := range b {
+		inB[cell] = true
+	}
+
+	for _, cell := range a {
+		if !inB[cell] {
+			return nil, false
+		}
+
+		delete(inB, cell)
+	}
+
+	return keys(inB), true
+}
+
+func addAll(set map[int]bool, cells []int) {
+	for _, cell := range cells {
+		set[cell] = true
+	}
+}
+
+func keys(set map[int]bool) []int {
+	cells := make([]int, 0, len(set))
+	for cell := range set {
+		cells = append(cells, cell)
+	}
+
+	return cells
+}
@@ -0,0 +1,191 @@
+package minesweeper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlayerColors are the cursor colors of the players of a team, given out in
+// the order the players join.
+var PlayerColors = []Color{"bright-red", "bright-blue", "bright-green", "bright-magenta", "bright-yellow", "bright-cyan", "208", "141"}
+
+// Player is a player of a team.
+type Player struct {
+	Name string
+	// Color is the color of the player's cursor
+	Color Color
+	// Cursor is the cell the player looks at, it follows the player's moves
+	Cursor Position
+
+	// Moves is the number of moves the player made
+	Moves int
+	// Revealed is the number of safe cells the player cleared
+	Revealed int
+	// Flags is the number of the player's flags on the board
+	Flags int
+	// HitMine is set if the player revealed a mine
+	HitMine bool
+	// Left is set once the player left the team
+	Left bool
+}
+
+// Team is a group of players sharing a board. Moves made with Play are
+// attributed to the player making them. A loss is a loss for the whole
+// team, as there is only one board.
+//
+// A team is not safe for concurrent use, like the board it belongs to. With
+// a Session, use it in Do.
+type Team struct {
+	board   *Board
+	players []*Player
+	// owners is the player who revealed or flagged each cell
+	owners map[Position]*Player
+	// active is the player whose move is being played, changed is set once
+	// the move changed the board
+	active  *Player
+	changed bool
+}
+
+// NewTeam creates a team without players for the board.
+func NewTeam(board *Board) *Team {
+	t := &Team{
+		board:  board,
+		owners: make(map[Position]*Player),
+	}
+
+	board.Subscribe(t.handleEvent)
+
+	return t
+}
+
+// Join adds a player to the team. A number is added to names that are taken,
+// an empty name becomes "player".
+func (t *Team) Join(name string) *Player {
+	p := &Player{
+		Name:   uniqueName(name, func(name string) bool { return t.Player(name) != nil }),
+		Color:  PlayerColors[len(t.players)%len(PlayerColors)],
+		Cursor: Position{Row: t.board.Rows / 2, Col: t.board.Cols / 2},
+	}
+
+	t.players = append(t.players, p)
+
+	return p
+}
+
+// Player returns the player with the name (case insensitive), or nil.
+func (t *Team) Player(name string) *Player {
+	for _, p := range t.players {
+		if equalName(p.Name, name) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// Leave marks the player as gone. The player is kept for the statistics,
+// but the cursor is no longer shown.
+func (t *Team) Leave(p *Player) {
+	p.Left = true
+}
+
+// Players returns the players in the order they joined, including the
+// players that left.
+func (t *Team) Players() []*Player {
+	return append([]*Player(nil), t.players...)
+}
+
+// Play plays a move of the player. The cells revealed and flagged by move
+// are attributed to the player, the move is counted if it changed the board.
+func (t *Team) Play(p *Player, move func() error) error {
+	t.active, t.changed = p, false
+	defer func() {
+		t.active = nil
+	}()
+
+	err := move()
+
+	if t.changed {
+		p.Moves++
+	}
+
+	return err
+}
+
+// Owner returns the player who revealed or flagged the cell, or nil.
+func (t *Team) Owner(row, col int) *Player {
+	return t.owners[Position{Row: row, Col: col}]
+}
+
+// Cursors returns the cursors of the players that didn't leave, in their
+// colors.
+func (t *Team) Cursors() []Cursor {
+	var cursors []Cursor
+	for _, p := range t.players {
+		if !p.Left {
+			cursors = append(cursors, Cursor{Position: p.Cursor, Color: p.Color})
+		}
+	}
+
+	return cursors
+}
+
+func (t *Team) handleEvent(event Event) {
+	p := t.active
+	if p == nil {
+		return
+	}
+
+	t.changed = true
+
+	switch e := event.(type) {
+	case CellsRevealedEvent:
+		for _, pos := range e.Cells {
+			t.owners[pos] = p
+
+			if !t.board.Cells[pos.Row][pos.Col].IsMine {
+				p.Revealed++
+			}
+		}
+
+		if len(e.Cells) > 0 {
+			p.Cursor = e.Cells[0]
+		}
+	case FlagChangedEvent:
+		pos := Position{Row: e.Row, Col: e.Col}
+
+		if e.Flagged {
+			t.owners[pos] = p
+			p.Flags++
+		} else if owner := t.owners[pos]; owner != nil {
+			owner.Flags--
+			delete(t.owners, pos)
+		}
+
+		p.Cursor = pos
+	case MineHitEvent:
+		p.HitMine = true
+		p.Cursor = Position{Row: e.Row, Col: e.Col}
+	}
+}
+
+// uniqueName returns the name with a number added if it is taken. An empty
+// name becomes "player".
+func uniqueName(name string, taken func(name string) bool) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "player"
+	}
+
+	unique := name
+	for i := 2; taken(unique); i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+
+	return unique
+}
+
+// equalName reports whether two player names are the same, ignoring case.
+func equalName(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
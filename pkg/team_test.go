@@ -0,0 +1,98 @@
+package minesweeper_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestTeam(t *testing.T) {
+	board := minesweeper.NewBoard(5, 5, 5, &minesweeper.BoardOptions{Seed: 3}, nil)
+	team := minesweeper.NewTeam(board)
+
+	alice := team.Join("alice")
+	bob := team.Join("Alice")
+
+	if bob.Name != "Alice2" {
+		t.Errorf("expected a unique name, got %q", bob.Name)
+	}
+
+	if alice.Color == bob.Color {
+		t.Errorf("expected different cursor colors, both are %q", alice.Color)
+	}
+
+	if team.Player("ALICE") != alice {
+		t.Error("expected to find alice by name")
+	}
+
+	var mine, number minesweeper.Position
+	for r := 0; r < board.Rows; r++ {
+		for c := 0; c < board.Cols; c++ {
+			switch {
+			case board.Cells[r][c].IsMine:
+				mine = minesweeper.Position{Row: r, Col: c}
+			case board.Cells[r][c].MinesAround > 0:
+				number = minesweeper.Position{Row: r, Col: c}
+			}
+		}
+	}
+
+	team.Play(alice, func() error {
+		board.Reveal(number.Row, number.Col)
+		return nil
+	})
+
+	team.Play(bob, func() error {
+		board.ToggleFlag(mine.Row, mine.Col)
+		return nil
+	})
+
+	// Moves of nobody in particular are not attributed
+	board.ToggleFlag(0, 0)
+
+	if alice.Revealed != 1 || alice.Moves != 1 || alice.Cursor != number {
+		t.Errorf("unexpected statistics of alice %+v", alice)
+	}
+
+	if bob.Flags != 1 || bob.Moves != 1 || bob.Cursor != mine {
+		t.Errorf("unexpected statistics of bob %+v", bob)
+	}
+
+	if team.Owner(number.Row, number.Col) != alice || team.Owner(mine.Row, mine.Col) != bob || team.Owner(0, 0) != nil {
+		t.Error("expected the cells to be owned by the players that played them")
+	}
+
+	// Removing bob's flag takes it from bob
+	team.Play(alice, func() error {
+		board.ToggleFlag(mine.Row, mine.Col)
+		return nil
+	})
+
+	if bob.Flags != 0 || alice.Flags != 0 {
+		t.Errorf("expected no flags, got %d and %d", alice.Flags, bob.Flags)
+	}
+
+	team.Play(bob, func() error {
+		board.Reveal(mine.Row, mine.Col)
+		return nil
+	})
+
+	if !bob.HitMine || bob.Revealed != 0 || board.Status() != minesweeper.StatusLost {
+		t.Errorf("expected bob to lose the game for the team, got %+v", bob)
+	}
+}
+
+func TestRenderCursors(t *testing.T) {
+	board := minesweeper.NewBoard(3, 3, 1, &minesweeper.BoardOptions{Seed: 1}, nil)
+
+	var buf bytes.Buffer
+	renderer := minesweeper.ANSIRenderer{Cursors: []minesweeper.Cursor{{Position: minesweeper.Position{Row: 1, Col: 1}, Color: "blue"}}}
+	renderer.Render(&buf, board.PlayerView(), board.DisplayOptions)
+
+	// Black on blue
+	if strings.Count(buf.String(), "\x1b[30;44m") != 1 {
+		t.Errorf("expected one cell on a blue background, got %q", buf.String())
+	}
+}
@@ -0,0 +1,415 @@
+package minesweeper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColorDepth is the number of colors a terminal supports.
+type ColorDepth int
+
+const (
+	// Color16 uses the 16 standard ANSI colors.
+	Color16 ColorDepth = iota
+	// Color256 uses the 256 color palette.
+	Color256
+	// ColorTrue uses 24-bit RGB colors.
+	ColorTrue
+)
+
+func (d ColorDepth) String() string {
+	switch d {
+	case Color16:
+		return "16"
+	case Color256:
+		return "256"
+	case ColorTrue:
+		return "truecolor"
+	}
+
+	return "unknown"
+}
+
+// ParseColorDepth parses "16", "256" or "truecolor" (also "24bit").
+func ParseColorDepth(s string) (ColorDepth, error) {
+	switch strings.ToLower(s) {
+	case "16":
+		return Color16, nil
+	case "256":
+		return Color256, nil
+	case "truecolor", "24bit":
+		return ColorTrue, nil
+	}
+
+	return Color16, fmt.Errorf("unknown color depth %q, use 16, 256 or truecolor", s)
+}
+
+// DetectColorDepth guesses the color depth of the terminal from the
+// COLORTERM and TERM environment variables.
+func DetectColorDepth() ColorDepth {
+	colorTerm := strings.ToLower(os.Getenv("COLORTERM"))
+	if colorTerm == "truecolor" || colorTerm == "24bit" {
+		return ColorTrue
+	}
+
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return Color256
+	}
+
+	return Color16
+}
+
+// Color is a color of a theme. It is one of the 16 named ANSI colors
+// ("red", "bright-blue", ...), an index of the 256 color palette ("208")
+// or an RGB color ("#ff8800"). An empty color is the terminal default.
+//
+// Colors are converted to the nearest color the terminal supports.
+type Color string
+
+// Names of the 16 ANSI colors in the order of their codes
+var colorNames = []string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"bright-black", "bright-red", "bright-green", "bright-yellow", "bright-blue", "bright-magenta", "bright-cyan", "bright-white",
+}
+
+// RGB values of the 16 ANSI colors (xterm defaults)
+var ansiRGB = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// Validate returns an error if the color can't be parsed.
+func (c Color) Validate() error {
+	_, _, err := c.parse()
+	return err
+}
+
+// Hex returns the color as #rrggbb, or "" if it can't be parsed.
+func (c Color) Hex() string {
+	_, rgb, err := c.parse()
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2])
+}
+
+// parse returns the index of a named color (-1 otherwise) or the RGB value
+// of the color. Colors of the 256 color palette are returned as RGB.
+func (c Color) parse() (ansi int, rgb [3]int, err error) {
+	s := strings.ToLower(strings.TrimSpace(string(c)))
+
+	if s == "gray" || s == "grey" {
+		s = "bright-black"
+	}
+
+	for i, name := range colorNames {
+		if s == name {
+			return i, ansiRGB[i], nil
+		}
+	}
+
+	if strings.HasPrefix(s, "#") && len(s) == 7 {
+		value, err := strconv.ParseUint(s[1:], 16, 32)
+		if err != nil {
+			return -1, rgb, fmt.Errorf("invalid RGB color %q", c)
+		}
+
+		return -1, [3]int{int(value >> 16 & 0xff), int(value >> 8 & 0xff), int(value & 0xff)}, nil
+	}
+
+	index, err := strconv.Atoi(s)
+	if err != nil || index < 0 || index > 255 {
+		return -1, rgb, fmt.Errorf("invalid color %q, use a name, a number from 0 to 255 or #rrggbb", c)
+	}
+
+	if index < 16 {
+		return index, ansiRGB[index], nil
+	}
+
+	return -1, paletteRGB(index), nil
+}
+
+// sgr returns the SGR parameters to use the color as foreground or background.
+func (c Color) sgr(background bool, depth ColorDepth) string {
+	if c == "" {
+		return ""
+	}
+
+	ansi, rgb, err := c.parse()
+	if err != nil {
+		return ""
+	}
+
+	// Named colors are the same on every terminal
+	if ansi < 0 {
+		switch depth {
+		case ColorTrue:
+			prefix := "38;2;"
+			if background {
+				prefix = "48;2;"
+			}
+
+			return fmt.Sprintf("%s%d;%d;%d", prefix, rgb[0], rgb[1], rgb[2])
+		case Color256:
+			prefix := "38;5;"
+			if background {
+				prefix = "48;5;"
+			}
+
+			return fmt.Sprintf("%s%d", prefix, nearestPalette(rgb))
+		}
+
+		ansi = nearestANSI(rgb)
+	}
+
+	code := 30 + ansi
+	if ansi >= 8 {
+		code = 90 + ansi - 8
+	}
+
+	if background {
+		code += 10
+	}
+
+	return strconv.Itoa(code)
+}
+
+// paletteRGB returns the RGB value of a color of the 256 color palette.
+func paletteRGB(index int) [3]int {
+	if index < 16 {
+		return ansiRGB[index]
+	}
+
+	if index >= 232 {
+		gray := 8 + (index-232)*10
+		return [3]int{gray, gray, gray}
+	}
+
+	levels := [6]int{0, 95, 135, 175, 215, 255}
+	index -= 16
+
+	return [3]int{levels[index/36], levels[index/6%6], levels[index%6]}
+}
+
+// nearestPalette returns the index of the 256 color palette closest to rgb.
+func nearestPalette(rgb [3]int) int {
+	best, bestDistance := 0, -1
+
+	for i := 16; i < 256; i++ {
+		if d := distance(rgb, paletteRGB(i)); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+
+	return best
+}
+
+// nearestANSI returns the index of the ANSI color closest to rgb.
+func nearestANSI(rgb [3]int) int {
+	best, bestDistance := 0, -1
+
+	for i, color := range ansiRGB {
+		if d := distance(rgb, color); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+
+	return best
+}
+
+func distance(a, b [3]int) int {
+	dr, dg, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dr*dr + dg*dg + db*db
+}
+
+// Style is how a part of the board is drawn.
+type Style struct {
+	Foreground Color `json:"fg,omitempty"`
+	Background Color `json:"bg,omitempty"`
+	Bold       bool  `json:"bold,omitempty"`
+	Reverse    bool  `json:"reverse,omitempty"`
+}
+
+// Paint returns the text with the escape codes of the style for the color
+// depth.
+func (s Style) Paint(text string, depth ColorDepth) string {
+	sgr := s.sgr(depth)
+	if sgr == "" {
+		return text
+	}
+
+	return "\x1b[" + sgr + "m" + text + "\x1b[0m"
+}
+
+// sgr returns the SGR parameters of the style, or "" if the style is empty.
+func (s Style) sgr(depth ColorDepth) string {
+	var params []string
+
+	if s.Bold {
+		params = append(params, "1")
+	}
+
+	if s.Reverse {
+		params = append(params, "7")
+	}
+
+	if fg := s.Foreground.sgr(false, depth); fg != "" {
+		params = append(params, fg)
+	}
+
+	if bg := s.Background.sgr(true, depth); bg != "" {
+		params = append(params, bg)
+	}
+
+	return strings.Join(params, ";")
+}
+
+func (s Style) validate() error {
+	if err := s.Foreground.Validate(); s.Foreground != "" && err != nil {
+		return err
+	}
+
+	if err := s.Background.Validate(); s.Background != "" && err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Theme holds the styles used to draw the board.
+type Theme struct {
+	Name string `json:"name"`
+
+	// Numbers holds the style of revealed cells by the number of mines around them
+	Numbers  [9]Style `json:"numbers"`
+	Hidden   Style    `json:"hidden"`
+	Flag     Style    `json:"flag"`
+	Mine     Style    `json:"mine"`
+	Exploded Style    `json:"exploded"`
+	Index    Style    `json:"index"`
+}
+
+// Built-in themes
+var (
+	ThemeClassic = Theme{
+		Name: "classic",
+		Numbers: [9]Style{
+			{Foreground: "bright-black"},
+			{Foreground: "bright-blue"},
+			{Foreground: "green"},
+			{Foreground: "red"},
+			{Foreground: "blue"},
+			{Foreground: "yellow"},
+			{Foreground: "cyan"},
+			{Foreground: "black"},
+			{Foreground: "bright-black"},
+		},
+		Hidden:   Style{Foreground: "white"},
+		Flag:     Style{Foreground: "bright-red"},
+		Mine:     Style{Background: "red"},
+		Exploded: Style{Background: "red"},
+		Index:    Style{Foreground: "blue"},
+	}
+
+	ThemeHighContrast = Theme{
+		Name: "high-contrast",
+		Numbers: [9]Style{
+			{Foreground: "white"},
+			{Foreground: "bright-cyan", Bold: true},
+			{Foreground: "bright-green", Bold: true},
+			{Foreground: "bright-red", Bold: true},
+			{Foreground: "bright-magenta", Bold: true},
+			{Foreground: "bright-yellow", Bold: true},
+			{Foreground: "bright-cyan", Bold: true},
+			{Foreground: "bright-white", Bold: true},
+			{Foreground: "bright-white", Bold: true},
+		},
+		Hidden:   Style{Foreground: "bright-white"},
+		Flag:     Style{Foreground: "black", Background: "bright-yellow", Bold: true},
+		Mine:     Style{Foreground: "bright-white", Background: "red", Bold: true},
+		Exploded: Style{Foreground: "black", Background: "bright-red", Bold: true},
+		Index:    Style{Foreground: "bright-white", Bold: true},
+	}
+
+	// ThemeColorBlind uses the Okabe-Ito palette, which stays distinguishable
+	// with the common forms of color blindness.
+	ThemeColorBlind = Theme{
+		Name: "colorblind",
+		Numbers: [9]Style{
+			{Foreground: "#999999"},
+			{Foreground: "#0072b2"},
+			{Foreground: "#009e73"},
+			{Foreground: "#d55e00"},
+			{Foreground: "#cc79a7"},
+			{Foreground: "#e69f00"},
+			{Foreground: "#56b4e9"},
+			{Foreground: "#f0e442"},
+			{Foreground: "#ffffff"},
+		},
+		Hidden:   Style{Foreground: "#bbbbbb"},
+		Flag:     Style{Foreground: "#e69f00", Bold: true},
+		Mine:     Style{Foreground: "#ffffff", Background: "#0072b2"},
+		Exploded: Style{Foreground: "#ffffff", Background: "#d55e00"},
+		Index:    Style{Foreground: "#56b4e9"},
+	}
+)
+
+// Themes lists the built-in themes.
+var Themes = []Theme{ThemeClassic, ThemeHighContrast, ThemeColorBlind}
+
+// ThemeByName returns the built-in theme with the given name.
+func ThemeByName(name string) (Theme, bool) {
+	for _, theme := range Themes {
+		if strings.EqualFold(theme.Name, name) {
+			return theme, true
+		}
+	}
+
+	return Theme{}, false
+}
+
+// LoadTheme reads a theme from a JSON file. Styles missing from the file are
+// taken from the classic theme.
+func LoadTheme(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+
+	return ParseTheme(data)
+}
+
+// ParseTheme parses a theme from JSON. Styles missing from the JSON are
+// taken from the classic theme.
+func ParseTheme(data []byte) (Theme, error) {
+	var raw struct {
+		Theme
+		Numbers []Style `json:"numbers"`
+	}
+
+	raw.Theme = ThemeClassic
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Theme{}, fmt.Errorf("invalid theme: %w", err)
+	}
+
+	if len(raw.Numbers) > len(raw.Theme.Numbers) {
+		return Theme{}, fmt.Errorf("invalid theme: %d number styles, at most %d are used", len(raw.Numbers), len(raw.Theme.Numbers))
+	}
+
+	theme := raw.Theme
+	copy(theme.Numbers[:], raw.Numbers)
+
+	styles := append(theme.Numbers[:], theme.Hidden, theme.Flag, theme.Mine, theme.Exploded, theme.Index)
+	for _, style := range styles {
+		if err := style.validate(); err != nil {
+			return Theme{}, fmt.Errorf("invalid theme: %w", err)
+		}
+	}
+
+	return theme, nil
+}
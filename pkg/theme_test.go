@@ -0,0 +1,66 @@
+package minesweeper_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestParseTheme(t *testing.T) {
+	theme, err := minesweeper.ParseTheme([]byte(`{"name": "custom", "numbers": [{"fg": "white"}, {"fg": "#ff8800"}], "flag": {"fg": "208", "bold": true}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if theme.Name != "custom" {
+		t.Errorf("Expected name to be %s, but got %s", "custom", theme.Name)
+	}
+
+	if theme.Numbers[1].Foreground != "#ff8800" {
+		t.Errorf("Expected color of 1 to be %s, but got %s", "#ff8800", theme.Numbers[1].Foreground)
+	}
+
+	// Missing styles are taken from the classic theme
+	if theme.Numbers[2] != minesweeper.ThemeClassic.Numbers[2] {
+		t.Errorf("Expected style of 2 to be %+v, but got %+v", minesweeper.ThemeClassic.Numbers[2], theme.Numbers[2])
+	}
+
+	if theme.Index != minesweeper.ThemeClassic.Index {
+		t.Errorf("Expected index style to be %+v, but got %+v", minesweeper.ThemeClassic.Index, theme.Index)
+	}
+
+	if _, err := minesweeper.ParseTheme([]byte(`{"hidden": {"fg": "purple-ish"}}`)); err == nil {
+		t.Error("Expected an error for an unknown color")
+	}
+}
+
+func TestThemeColorDepth(t *testing.T) {
+	rows, cols, numMines := 10, 10, 10
+	boardOptions := &minesweeper.BoardOptions{Seed: 5}
+
+	tests := []struct {
+		depth    minesweeper.ColorDepth
+		expected string
+	}{
+		{minesweeper.ColorTrue, "\x1b[38;2;187;187;187m•"},
+		{minesweeper.Color256, "\x1b[38;5;250m•"},
+		{minesweeper.Color16, "\x1b[37m•"},
+	}
+
+	for _, test := range tests {
+		depth := test.depth
+		theme := minesweeper.ThemeColorBlind
+		displayOptions := &minesweeper.DisplayOptions{Theme: &theme, ColorDepth: &depth}
+
+		board := minesweeper.NewBoard(rows, cols, numMines, boardOptions, displayOptions)
+
+		var buf bytes.Buffer
+		(minesweeper.ANSIRenderer{}).Render(&buf, board.PlayerView(), board.DisplayOptions)
+
+		if !strings.Contains(buf.String(), test.expected) {
+			t.Errorf("Expected %s output to contain %q", test.depth, test.expected)
+		}
+	}
+}
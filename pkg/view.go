@@ -0,0 +1,158 @@
+package minesweeper
+
+import (
+	"context"
+	"strings"
+)
+
+// CellState is what the player knows about a cell.
+type CellState int
+
+const (
+	// CellHidden is a cell that is not revealed.
+	CellHidden CellState = iota
+	// CellFlagged is a hidden cell with a flag.
+	CellFlagged
+	// CellRevealed is a revealed cell without a mine, MinesAround is set.
+	CellRevealed
+	// CellMine is a hidden mine, only shown once the game is over.
+	CellMine
+	// CellExploded is a revealed mine, the one that lost the game.
+	CellExploded
+)
+
+// Characters of the cells in text protocols, numbers are '0'-'8'
+const (
+	CharHidden = '.'
+	CharFlag   = 'F'
+	CharMine   = '*'
+)
+
+// CellView is a cell as seen by the player.
+type CellView struct {
+	State CellState
+	// MinesAround is the number of mines around a revealed cell, 0 otherwise
+	MinesAround int
+}
+
+// Char returns the character of the cell: '.' hidden, 'F' flagged, '0'-'8'
+// revealed and '*' a mine.
+func (c CellView) Char() byte {
+	switch c.State {
+	case CellFlagged:
+		return CharFlag
+	case CellRevealed:
+		return byte('0' + c.MinesAround)
+	case CellMine, CellExploded:
+		return CharMine
+	}
+
+	return CharHidden
+}
+
+// PlayerView is a read-only snapshot of the board as seen by the player. It
+// holds no hidden mines while the game is being played, so it is safe to
+// send to players, bots and renderers.
+type PlayerView struct {
+	Rows     int
+	Cols     int
+	NumMines int
+	// MinesLeft is the number of mines minus the number of flags
+	MinesLeft int
+	Flags     int
+	Revealed  int
+	Hidden    int
+	Status    GameStatus
+	Cells     [][]CellView
+}
+
+// PlayerView returns the board as seen by the player. Hidden mines are only
+// shown once the game is over.
+func (b *Board) PlayerView() *PlayerView {
+	return b.playerView(b.Status() != StatusPlaying)
+}
+
+// EndView returns the board as seen by a player who stopped playing: hidden
+// mines are shown even if the game is not over. It must not be shown to
+// anyone still playing the board.
+func (b *Board) EndView() *PlayerView {
+	return b.playerView(true)
+}
+
+func (b *Board) playerView(showMines bool) *PlayerView {
+	cells := make([][]CellView, b.Rows)
+	for r := range cells {
+		cells[r] = make([]CellView, b.Cols)
+		for c := range cells[r] {
+			cells[r][c] = b.cellView(r, c, showMines)
+		}
+	}
+
+	flags := b.FlagsCount()
+	revealed := b.CellsRevealed()
+
+	return &PlayerView{
+		Rows:      b.Rows,
+		Cols:      b.Cols,
+		NumMines:  b.NumMines,
+		MinesLeft: b.NumMines - flags,
+		Flags:     flags,
+		Revealed:  revealed,
+		Hidden:    b.Rows*b.Cols - revealed,
+		Status:    b.Status(),
+		Cells:     cells,
+	}
+}
+
+// CellView returns the cell at row, col as seen by the player, like
+// PlayerView.
+func (b *Board) CellView(row, col int) CellView {
+	return b.cellView(row, col, b.Status() != StatusPlaying)
+}
+
+// cellView returns the cell as seen by the player. Flags stay on mines
+// when they are shown.
+func (b *Board) cellView(row, col int, showMines bool) CellView {
+	cell := b.Cells[row][col]
+
+	switch {
+	case cell.IsRevealed && cell.IsMine:
+		return CellView{State: CellExploded}
+	case cell.IsRevealed:
+		return CellView{State: CellRevealed, MinesAround: cell.MinesAround}
+	case cell.IsFlagged:
+		return CellView{State: CellFlagged}
+	case cell.IsMine && showMines:
+		return CellView{State: CellMine}
+	}
+
+	return CellView{State: CellHidden}
+}
+
+// Lines returns one string per row with the Char of every cell.
+func (v *PlayerView) Lines() []string {
+	lines := make([]string, v.Rows)
+	for r, cells := range v.Cells {
+		var sb strings.Builder
+
+		for _, cell := range cells {
+			sb.WriteByte(cell.Char())
+		}
+
+		lines[r] = sb.String()
+	}
+
+	return lines
+}
+
+// PlayerView returns the board as seen by the player.
+func (s *Session) PlayerView(ctx context.Context) (*PlayerView, error) {
+	var view *PlayerView
+
+	err := s.Do(ctx, func(board *Board) error {
+		view = board.PlayerView()
+		return nil
+	})
+
+	return view, err
+}
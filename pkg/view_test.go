@@ -0,0 +1,75 @@
+package minesweeper_test
+
+import (
+	"strings"
+	"testing"
+
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestPlayerView(t *testing.T) {
+	board := minesweeper.NewBoard(5, 5, 5, &minesweeper.BoardOptions{Seed: 3}, nil)
+
+	var mines []minesweeper.Position
+	var safe minesweeper.Position
+	for r := 0; r < board.Rows; r++ {
+		for c := 0; c < board.Cols; c++ {
+			if board.Cells[r][c].IsMine {
+				mines = append(mines, minesweeper.Position{Row: r, Col: c})
+			} else if board.Cells[r][c].MinesAround > 0 {
+				safe = minesweeper.Position{Row: r, Col: c}
+			}
+		}
+	}
+
+	board.Reveal(safe.Row, safe.Col)
+	board.ToggleFlag(mines[0].Row, mines[0].Col)
+
+	view := board.PlayerView()
+
+	if view.Rows != 5 || view.Cols != 5 || view.NumMines != 5 || view.Flags != 1 || view.MinesLeft != 4 || view.Revealed != 1 || view.Hidden != 24 {
+		t.Errorf("unexpected counters %+v", view)
+	}
+
+	if view.Status != minesweeper.StatusPlaying {
+		t.Errorf("expected the game to be playing, got %v", view.Status)
+	}
+
+	if got := view.Cells[safe.Row][safe.Col]; got.State != minesweeper.CellRevealed || got.MinesAround != board.Cells[safe.Row][safe.Col].MinesAround {
+		t.Errorf("expected the revealed number, got %+v", got)
+	}
+
+	// No mine may be visible while the game is played
+	lines := strings.Join(view.Lines(), "")
+	if strings.Count(lines, "*") != 0 || strings.Count(lines, "F") != 1 || strings.Count(lines, ".") != 23 {
+		t.Errorf("expected 1 number, 1 flag and 23 hidden cells, got %q", lines)
+	}
+
+	if board.EndView().Cells[mines[1].Row][mines[1].Col].State != minesweeper.CellMine {
+		t.Error("expected the end view to show the mines")
+	}
+
+	// Losing shows the mines, flags stay on the mines they mark
+	board.Reveal(mines[1].Row, mines[1].Col)
+	view = board.PlayerView()
+
+	if view.Status != minesweeper.StatusLost {
+		t.Fatalf("expected the game to be lost, got %v", view.Status)
+	}
+
+	want := map[minesweeper.Position]minesweeper.CellState{
+		mines[0]: minesweeper.CellFlagged,
+		mines[1]: minesweeper.CellExploded,
+		mines[2]: minesweeper.CellMine,
+	}
+
+	for pos, state := range want {
+		if got := view.Cells[pos.Row][pos.Col].State; got != state {
+			t.Errorf("cell %v: expected state %d, got %d", pos, state, got)
+		}
+
+		if got := board.CellView(pos.Row, pos.Col); got != view.Cells[pos.Row][pos.Col] {
+			t.Errorf("cell %v: CellView %+v differs from the view", pos, got)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package minesweeper
+
+import "strings"
+
+// Viewport is the window of the board that is rendered. Row and Col are the
+// top left cell of the window.
+type Viewport struct {
+	Row  int
+	Col  int
+	Rows int
+	Cols int
+}
+
+// Maximum size of the minimap
+const (
+	minimapRows = 8
+	minimapCols = 16
+)
+
+// view returns the viewport of the display options limited to the board, or
+// the whole board if no viewport is set.
+func (b *Board) view() Viewport {
+	if b.DisplayOptions.Viewport == nil {
+		return Viewport{Rows: b.Rows, Cols: b.Cols}
+	}
+
+	v := *b.DisplayOptions.Viewport
+	v.fit(b)
+
+	return v
+}
+
+// fit limits the viewport to the board.
+func (v *Viewport) fit(b *Board) {
+	if v.Rows <= 0 || v.Rows > b.Rows {
+		v.Rows = b.Rows
+	}
+
+	if v.Cols <= 0 || v.Cols > b.Cols {
+		v.Cols = b.Cols
+	}
+
+	v.Row = clamp(v.Row, 0, b.Rows-v.Rows)
+	v.Col = clamp(v.Col, 0, b.Cols-v.Cols)
+}
+
+// Contains reports whether the cell at row, col is inside the viewport.
+func (v *Viewport) Contains(row, col int) bool {
+	return row >= v.Row && row < v.Row+v.Rows && col >= v.Col && col < v.Col+v.Cols
+}
+
+// Resize changes the size of the viewport and keeps it on the board.
+func (v *Viewport) Resize(b *Board, rows, cols int) {
+	v.Rows, v.Cols = rows, cols
+	v.fit(b)
+}
+
+// Pan moves the viewport by the given number of rows and columns.
+func (v *Viewport) Pan(b *Board, rows, cols int) {
+	v.Row += rows
+	v.Col += cols
+	v.fit(b)
+}
+
+// CenterOn moves the viewport so the cell at row, col is in its center.
+func (v *Viewport) CenterOn(b *Board, row, col int) {
+	v.fit(b)
+	v.Row = row - v.Rows/2
+	v.Col = col - v.Cols/2
+	v.fit(b)
+}
+
+// Follow moves the viewport as little as possible to show the cell at row, col.
+func (v *Viewport) Follow(b *Board, row, col int) {
+	v.fit(b)
+
+	if row < v.Row {
+		v.Row = row
+	} else if row >= v.Row+v.Rows {
+		v.Row = row - v.Rows + 1
+	}
+
+	if col < v.Col {
+		v.Col = col
+	} else if col >= v.Col+v.Cols {
+		v.Col = col - v.Cols + 1
+	}
+
+	v.fit(b)
+}
+
+// minimap returns the lines of a small map of the board. '#' marks the part
+// shown by the viewport and '.' the rest. It returns nil if the whole board
+// is shown.
+func (b *Board) minimap(v Viewport) []string {
+	if v.Rows == b.Rows && v.Cols == b.Cols {
+		return nil
+	}
+
+	rows := minInt(b.Rows, minimapRows, v.Rows)
+	cols := minInt(b.Cols, minimapCols)
+
+	lines := make([]string, rows)
+	for r := 0; r < rows; r++ {
+		var sb strings.Builder
+
+		// Board rows and columns covered by this character of the minimap
+		fromRow, toRow := r*b.Rows/rows, (r+1)*b.Rows/rows
+		for c := 0; c < cols; c++ {
+			fromCol, toCol := c*b.Cols/cols, (c+1)*b.Cols/cols
+
+			if fromRow < v.Row+v.Rows && toRow > v.Row && fromCol < v.Col+v.Cols && toCol > v.Col {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+
+		lines[r] = sb.String()
+	}
+
+	return lines
+}
+
+func clamp(value, low, high int) int {
+	if value > high {
+		value = high
+	}
+
+	if value < low {
+		value = low
+	}
+
+	return value
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
+
+func maxInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v > m {
+			m = v
+		}
+	}
+
+	return m
+}
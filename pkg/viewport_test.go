@@ -0,0 +1,69 @@
+package minesweeper_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/TechMDW/minesweeper/internal/util"
+	minesweeper "github.com/TechMDW/minesweeper/pkg"
+)
+
+func TestViewport(t *testing.T) {
+	rows, cols, numMines := 50, 200, 10
+	boardOptions := &minesweeper.BoardOptions{Seed: 5}
+	displayOptions := &minesweeper.DisplayOptions{
+		Viewport: &minesweeper.Viewport{Rows: 5, Cols: 10},
+		Minimap:  util.BoolPtr(true),
+	}
+
+	board := minesweeper.NewBoard(rows, cols, numMines, boardOptions, displayOptions)
+	board.DisplayOptions.Viewport.CenterOn(board, 20, 100)
+
+	viewport := board.DisplayOptions.Viewport
+	if viewport.Row != 18 || viewport.Col != 95 {
+		t.Errorf("Expected viewport at (18, 95), but got (%d, %d)", viewport.Row, viewport.Col)
+	}
+
+	var buf bytes.Buffer
+	(minesweeper.PlainRenderer{}).Render(&buf, board.PlayerView(), board.DisplayOptions)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("Expected %d lines, but got %d", 6, len(lines))
+	}
+
+	// Indices show absolute positions, cells are widened for three digit indices
+	if !strings.HasPrefix(lines[0], "    96  97  98") {
+		t.Errorf("Expected top index to start at 96, but got %q", lines[0])
+	}
+
+	if !strings.HasPrefix(lines[1], "19| ") {
+		t.Errorf("Expected first row to be 19, but got %q", lines[1])
+	}
+
+	// Minimap in the corner, each character covers 10 rows and 12.5 columns
+	if !strings.HasSuffix(lines[1], "   ................") {
+		t.Errorf("Expected empty minimap line after the first row, but got %q", lines[1])
+	}
+
+	if !strings.HasSuffix(lines[2], "   .......##.......") {
+		t.Errorf("Expected viewport on the minimap after the second row, but got %q", lines[2])
+	}
+
+	pos, ok := board.CellAt(4, 1)
+	if !ok || pos.Row != 18 || pos.Col != 95 {
+		t.Errorf("Expected cell (18, 95), but got (%d, %d)", pos.Row, pos.Col)
+	}
+
+	// Moving the viewport stops at the edge of the board
+	viewport.Pan(board, 100, 100)
+	if viewport.Row != rows-5 || viewport.Col != cols-10 {
+		t.Errorf("Expected viewport at (%d, %d), but got (%d, %d)", rows-5, cols-10, viewport.Row, viewport.Col)
+	}
+
+	viewport.Follow(board, 0, 0)
+	if viewport.Row != 0 || viewport.Col != 0 {
+		t.Errorf("Expected viewport at (0, 0), but got (%d, %d)", viewport.Row, viewport.Col)
+	}
+}